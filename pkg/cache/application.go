@@ -21,8 +21,11 @@ package cache
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/looplab/fsm"
 	"go.uber.org/zap"
@@ -58,11 +61,30 @@ type Application struct {
 	placeholderAsk             *si.Resource // total placeholder request for the app (all task groups)
 	placeholderTimeoutInSec    int64
 	schedulingStyle            string
-	originatingTask            *Task // Original Pod which creates the requests
+	originatingTask            *Task        // Original Pod which creates the requests
+	lastActivity               atomic.Int64 // unix nano time of the last task state change
+	scheduleAttempts           atomic.Int64 // number of Schedule calls with waiting tasks since the last task was bound
+	firstBind                  atomic.Int64 // unix nano time of the first task binding, 0 if none
+	paused                     atomic.Bool  // paused applications are skipped when scheduling
+	originPodNamespace         string       // namespace of the first pod added to the application
+	originPodName              string       // name of the first pod added to the application
+	resourceHistory            []ResourceSample
+	submissionTime             time.Time // time the application was created in the shim
+	failureReason              string    // reason the application failed, empty if it did not fail
+	preemptionVictims          []*Task   // tasks released by the core to preempt them, oldest first
+}
+
+// ResourceSample records the resources allocated to an application at a point in time
+type ResourceSample struct {
+	Time     time.Time
+	Resource *si.Resource
 }
 
 const transitionErr = "no transition"
 
+// maxPreemptionVictims is the number of preempted tasks kept per application
+const maxPreemptionVictims = 100
+
 func (app *Application) String() string {
 	return fmt.Sprintf("applicationID: %s, queue: %s, partition: %s,"+
 		" totalNumOfTasks: %d, currentState: %s",
@@ -85,6 +107,7 @@ func NewApplication(appID, queueName, user string, groups []string, tags map[str
 		schedulerAPI:            scheduler,
 		placeholderTimeoutInSec: 0,
 		schedulingStyle:         constants.SchedulingPolicyStyleParamDefault,
+		submissionTime:          timeNow(),
 	}
 	return app
 }
@@ -137,6 +160,17 @@ func (app *Application) GetQueue() string {
 	return app.queue
 }
 
+// GetTeam returns the team of the application, empty if not known.
+// The team tag is only set from the pod that submits the application and never changes afterwards.
+func (app *Application) GetTeam() string {
+	return app.tags[constants.AppTagTeam]
+}
+
+// GetSubmissionTime returns the time the application was created in the shim.
+func (app *Application) GetSubmissionTime() time.Time {
+	return app.submissionTime
+}
+
 func (app *Application) GetUser() string {
 	app.lock.RLock()
 	defer app.lock.RUnlock()
@@ -188,6 +222,44 @@ func (app *Application) getTaskGroups() []TaskGroup {
 	return app.taskGroups
 }
 
+// GetPreemptedTasks returns the tasks of the application that were released by the core to preempt them,
+// oldest first. Only the last maxPreemptionVictims tasks are kept.
+func (app *Application) GetPreemptedTasks() []*Task {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	victims := make([]*Task, len(app.preemptionVictims))
+	copy(victims, app.preemptionVictims)
+	return victims
+}
+
+// recordPreemptionVictim adds the task to the preemption victims of the application, dropping the oldest victim
+// if the list is full. Must be called with the application lock held.
+func (app *Application) recordPreemptionVictim(task *Task) {
+	if slices.Contains(app.preemptionVictims, task) {
+		return
+	}
+	if len(app.preemptionVictims) >= maxPreemptionVictims {
+		app.preemptionVictims = app.preemptionVictims[1:]
+	}
+	app.preemptionVictims = append(app.preemptionVictims, task)
+}
+
+// GetTaskGroups returns the sorted distinct task group names of the tasks of the application.
+func (app *Application) GetTaskGroups() []string {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	for _, task := range app.taskMap {
+		if name := task.getTaskGroupName(); name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (app *Application) setPlaceholderOwnerReferences(ref []metav1.OwnerReference) {
 	app.lock.Lock()
 	defer app.lock.Unlock()
@@ -218,6 +290,116 @@ func (app *Application) GetOriginatingTask() *Task {
 	return app.originatingTask
 }
 
+// GetLastActivity returns the last time any task of the application changed state.
+// A zero time is returned if none of the tasks has changed state yet.
+func (app *Application) GetLastActivity() time.Time {
+	if lastActivity := app.lastActivity.Load(); lastActivity != 0 {
+		return time.Unix(0, lastActivity)
+	}
+	return time.Time{}
+}
+
+// recordActivity is called from the task state machine callbacks while the task lock is held,
+// it must not acquire the application lock.
+func (app *Application) recordActivity() {
+	app.lastActivity.Store(timeNow().UnixNano())
+}
+
+// GetScheduleAttempts returns the number of times the application was scheduled with tasks waiting for an allocation
+// since a task was last bound.
+func (app *Application) GetScheduleAttempts() int64 {
+	return app.scheduleAttempts.Load()
+}
+
+// GetBindThroughput returns the number of tasks bound per second during the window that ends now.
+func (app *Application) GetBindThroughput(window time.Duration) float64 {
+	if window <= 0 {
+		return 0
+	}
+	cutoff := timeNow().Add(-window)
+	bound := 0
+	for _, task := range app.GetAllTasks() {
+		for _, transition := range task.GetTransitions() {
+			if transition.To == TaskStates().Bound && transition.Time.After(cutoff) {
+				bound++
+			}
+		}
+	}
+	return float64(bound) / window.Seconds()
+}
+
+// GetResourceHistory returns the samples of the resources allocated to the application, oldest first
+func (app *Application) GetResourceHistory() []ResourceSample {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	history := make([]ResourceSample, len(app.resourceHistory))
+	copy(history, app.resourceHistory)
+	return history
+}
+
+// sampleResources records the resources allocated to the bound tasks if the sampling interval has passed since
+// the last sample. Samples older than the retention period are dropped.
+func (app *Application) sampleResources(now time.Time) {
+	schedulerConf := conf.GetSchedulerConf()
+	interval := schedulerConf.ResourceSampleInterval
+	if interval <= 0 {
+		return
+	}
+	allocated := common.NewResourceBuilder().Build()
+	for _, task := range app.GetBoundTasks() {
+		allocated = common.Add(allocated, task.getResource())
+	}
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	if n := len(app.resourceHistory); n > 0 && now.Sub(app.resourceHistory[n-1].Time) < interval {
+		return
+	}
+	cutoff := now.Add(-schedulerConf.ResourceSampleRetention)
+	keep := 0
+	for keep < len(app.resourceHistory) && app.resourceHistory[keep].Time.Before(cutoff) {
+		keep++
+	}
+	app.resourceHistory = append(app.resourceHistory[keep:], ResourceSample{Time: now, Resource: allocated})
+}
+
+// resetScheduleAttempts is called from the task state machine callbacks while the task lock is held,
+// it must not acquire the application lock.
+func (app *Application) resetScheduleAttempts() {
+	app.scheduleAttempts.Store(0)
+}
+
+// recordFirstBind records the time of the first task binding of the application. It is called from the task state
+// machine callbacks while the task lock is held, it must not acquire the application lock.
+func (app *Application) recordFirstBind() {
+	app.firstBind.CompareAndSwap(0, timeNow().UnixNano())
+}
+
+// GetFirstScheduleLatency returns the time between the submission of the application and the binding of its first
+// task. The boolean is false if no task has been bound yet.
+func (app *Application) GetFirstScheduleLatency() (time.Duration, bool) {
+	firstBind := app.firstBind.Load()
+	if firstBind == 0 {
+		return 0, false
+	}
+	return time.Unix(0, firstBind).Sub(app.submissionTime), true
+}
+
+// GetFailureReason returns the reason the application failed, empty if it did not fail.
+func (app *Application) GetFailureReason() string {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	return app.failureReason
+}
+
+// SetPaused pauses or resumes the scheduling of the application. Tasks that are already scheduled are not affected.
+func (app *Application) SetPaused(paused bool) {
+	app.paused.Store(paused)
+}
+
+func (app *Application) IsPaused() bool {
+	return app.paused.Load()
+}
+
 func (app *Application) addTask(task *Task) {
 	app.lock.Lock()
 	defer app.lock.Unlock()
@@ -226,6 +408,20 @@ func (app *Application) addTask(task *Task) {
 		return
 	}
 	app.taskMap[task.taskID] = task
+	if app.originPodName == "" {
+		if pod := task.GetTaskPod(); pod != nil {
+			app.originPodNamespace = pod.Namespace
+			app.originPodName = pod.Name
+		}
+	}
+}
+
+// GetOriginPod returns the namespace and name of the first pod that was added to the application.
+// Empty strings are returned if no pod has been added yet.
+func (app *Application) GetOriginPod() (namespace, name string) {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	return app.originPodNamespace, app.originPodName
 }
 
 func (app *Application) RemoveTask(taskID string) {
@@ -261,6 +457,12 @@ func (app *Application) GetNewTasks() []*Task {
 	return app.getTasks(TaskStates().New)
 }
 
+func (app *Application) GetSchedulingTasks() []*Task {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	return app.getTasks(TaskStates().Scheduling)
+}
+
 func (app *Application) GetAllocatedTasks() []*Task {
 	app.lock.RLock()
 	defer app.lock.RUnlock()
@@ -273,6 +475,16 @@ func (app *Application) GetBoundTasks() []*Task {
 	return app.getTasks(TaskStates().Bound)
 }
 
+// GetTasksByNode returns the bound tasks of the application grouped by the node they are bound to
+func (app *Application) GetTasksByNode() map[string][]*Task {
+	tasksByNode := make(map[string][]*Task)
+	for _, task := range app.GetBoundTasks() {
+		nodeName := task.getNodeName()
+		tasksByNode[nodeName] = append(tasksByNode[nodeName], task)
+	}
+	return tasksByNode
+}
+
 func (app *Application) GetPlaceHolderTasks() []*Task {
 	app.lock.RLock()
 	defer app.lock.RUnlock()
@@ -310,6 +522,30 @@ func (app *Application) getTasks(state string) []*Task {
 	return taskList
 }
 
+// GetAllTasks returns all tasks of the application
+func (app *Application) GetAllTasks() []*Task {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	taskList := make([]*Task, 0, len(app.taskMap))
+	for _, task := range app.taskMap {
+		taskList = append(taskList, task)
+	}
+	return taskList
+}
+
+// GetNonTerminatedTasks returns all tasks of the application that have not reached a terminated state
+func (app *Application) GetNonTerminatedTasks() []*Task {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	taskList := make([]*Task, 0)
+	for _, task := range app.taskMap {
+		if !task.isTerminated() {
+			taskList = append(taskList, task)
+		}
+	}
+	return taskList
+}
+
 func (app *Application) GetTags() map[string]string {
 	return app.tags
 }
@@ -348,6 +584,12 @@ func (app *Application) TriggerAppSubmission() error {
 // do nothing more than just triggering the state transition.
 // return true if the app needs scheduling or false if not
 func (app *Application) Schedule() bool {
+	app.sampleResources(timeNow())
+	if app.IsPaused() {
+		log.Log(log.ShimCacheApplication).Debug("skipping scheduling paused application",
+			zap.String("appID", app.GetApplicationID()))
+		return false
+	}
 	switch app.GetApplicationState() {
 	case ApplicationStates().New:
 		ev := NewSubmitApplicationEvent(app.GetApplicationID())
@@ -364,9 +606,11 @@ func (app *Application) Schedule() bool {
 	case ApplicationStates().Reserving:
 		// during the Reserving state, only the placeholders
 		// can be scheduled
-		app.scheduleTasks(func(t *Task) bool {
+		isPlaceholder := func(t *Task) bool {
 			return t.placeholder
-		})
+		}
+		app.scheduleTasks(isPlaceholder)
+		app.countScheduleAttempt(isPlaceholder)
 		app.removeCompletedTasks()
 		if len(app.GetNewTasks()) == 0 {
 			return false
@@ -374,9 +618,11 @@ func (app *Application) Schedule() bool {
 	case ApplicationStates().Running:
 		// during the Running state, only the regular pods
 		// can be scheduled
-		app.scheduleTasks(func(t *Task) bool {
+		isRegular := func(t *Task) bool {
 			return !t.placeholder
-		})
+		}
+		app.scheduleTasks(isRegular)
+		app.countScheduleAttempt(isRegular)
 		app.removeCompletedTasks()
 		if len(app.GetNewTasks()) == 0 {
 			return false
@@ -391,11 +637,38 @@ func (app *Application) Schedule() bool {
 	return true
 }
 
+// countScheduleAttempt counts a schedule attempt if a task matching the condition is still waiting for an allocation.
+// Each waiting task matching the condition has the scheduling cycle counted.
+func (app *Application) countScheduleAttempt(taskScheduleCondition func(t *Task) bool) {
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	considered := false
+	for _, state := range []string{TaskStates().New, TaskStates().Pending, TaskStates().Scheduling} {
+		for _, task := range app.getTasks(state) {
+			if taskScheduleCondition(task) {
+				task.incSchedulingCycles()
+				considered = true
+			}
+		}
+	}
+	if considered {
+		app.scheduleAttempts.Add(1)
+	}
+}
+
 func (app *Application) scheduleTasks(taskScheduleCondition func(t *Task) bool) {
 	for _, task := range app.GetNewTasks() {
 		if taskScheduleCondition(task) {
 			// for each new task, we do a sanity check before moving the state to Pending_Schedule
 			if err := task.sanityCheckBeforeScheduling(); err == nil {
+				// the rate limit is checked here, outside the dispatcher: the task stays new and is retried
+				// in the next scheduling cycle, like the following tasks of the application
+				if !task.context.tryAcceptAllocationRequest() {
+					log.Log(log.ShimCacheApplication).Debug("allocation request rate limit reached, delaying task",
+						zap.String("appID", task.applicationID),
+						zap.String("taskID", task.taskID))
+					return
+				}
 				// note, if we directly trigger submit task event, it may spawn too many duplicate
 				// events, because a task might be submitted multiple times before its state transits to PENDING.
 				if handleErr := task.handle(
@@ -546,7 +819,13 @@ func (app *Application) onReservationStateChange() {
 }
 
 func (app *Application) handleRejectApplicationEvent(reason string) {
-	log.Log(log.ShimCacheApplication).Info("app is rejected by scheduler", zap.String("appID", app.applicationID))
+	log.Log(log.ShimCacheApplication).Info("app is rejected by scheduler",
+		zap.String("appID", app.applicationID),
+		zap.String("reason", reason))
+	for _, task := range app.taskMap {
+		events.GetRecorder().Eventf(task.GetTaskPod().DeepCopy(), nil, v1.EventTypeWarning, "ApplicationRejected", "ApplicationRejected",
+			"Application %s is rejected by the scheduler, reason: %s", app.applicationID, reason)
+	}
 	// for rejected apps, we directly move them to failed state
 	dispatcher.Dispatch(NewFailApplicationEvent(app.applicationID,
 		fmt.Sprintf("%s: %s", constants.ApplicationRejectedFailure, reason)))
@@ -579,6 +858,7 @@ func (app *Application) handleFailApplicationEvent(errMsg string) {
 		getPlaceholderManager().cleanUp(app)
 	}()
 	log.Log(log.ShimCacheApplication).Info("failApplication reason", zap.String("applicationID", app.applicationID), zap.String("errMsg", errMsg))
+	app.failureReason = errMsg
 	// unallocated task states include New, Pending and Scheduling
 	unalloc := app.getTasks(TaskStates().New)
 	unalloc = append(unalloc, app.getTasks(TaskStates().Pending)...)
@@ -589,7 +869,7 @@ func (app *Application) handleFailApplicationEvent(errMsg string) {
 		// Only need to fail the non-placeholder pod(s)
 		if strings.Contains(errMsg, constants.ApplicationInsufficientResourcesFailure) {
 			failTaskPodWithReasonAndMsg(task, constants.ApplicationInsufficientResourcesFailure, "Scheduling has timed out due to insufficient resources")
-		} else if strings.Contains(errMsg, constants.ApplicationRejectedFailure) {
+		} else if strings.Contains(errMsg, constants.ApplicationRejectedFailure) && conf.GetSchedulerConf().FailTasksOnAppReject {
 			errMsgArr := strings.Split(errMsg, ":")
 			failTaskPodWithReasonAndMsg(task, constants.ApplicationRejectedFailure, errMsgArr[1])
 		}
@@ -607,6 +887,9 @@ func (app *Application) handleReleaseAppAllocationEvent(allocationKey string, te
 	for _, task := range app.taskMap {
 		if task.allocationKey == allocationKey {
 			task.setTaskTerminationType(terminationType)
+			if terminationType == si.TerminationType_name[int32(si.TerminationType_PREEMPTED_BY_SCHEDULER)] {
+				app.recordPreemptionVictim(task)
+			}
 			err := task.DeleteTaskPod()
 			if err != nil {
 				log.Log(log.ShimCacheApplication).Error("failed to release allocation from application", zap.Error(err))
@@ -674,7 +957,8 @@ func (app *Application) removeCompletedTasks() {
 	app.lock.Lock()
 	defer app.lock.Unlock()
 	for _, task := range app.taskMap {
-		if task.isTerminated() {
+		// a task with a pending release retry is kept until the release is done
+		if task.isTerminated() && !task.isReleasePending() {
 			app.removeTask(task.taskID)
 		}
 	}
@@ -415,6 +415,61 @@ func TestSetUnallocatedPodsToFailedWhenRejectApplication(t *testing.T) {
 	events.SetRecorder(k8sEvents.NewFakeRecorder(1024))
 }
 
+func TestRejectApplicationFailTasks(t *testing.T) {
+	recorder := setTestRecorder(t)
+
+	for _, failTasks := range []bool{true, false} {
+		setTestConf(t, func(c *conf.SchedulerConf) {
+			c.FailTasksOnAppReject = failTasks
+		})
+
+		context := initContextForTest()
+		dispatcher.RegisterEventHandler("TestAppHandler", dispatcher.EventTypeApp, context.ApplicationEventHandler())
+		dispatcher.Start()
+		mgr := NewPlaceholderManager(context.apiProvider.GetAPIs())
+
+		kubeClient := context.apiProvider.GetAPIs().KubeClient
+		pod, err := kubeClient.Create(newPodHelper(pod1Name, "default", pod1UID, "", appID1, v1.PodPending))
+		assert.NilError(t, err)
+		app := context.AddApplication(&AddApplicationRequest{
+			Metadata: ApplicationMetadata{
+				ApplicationID: appID1,
+				QueueName:     "root.abc",
+				User:          "testuser",
+			},
+		})
+		task := NewTask(pod1UID, app, context, pod)
+		task.sm.SetState(TaskStates().Pending)
+		app.addTask(task)
+		app.SetState(ApplicationStates().Submitted)
+
+		dispatcher.Dispatch(NewApplicationEvent(appID1, RejectApplication, "queue root.abc not found"))
+		// the rejected app always fails, the toggle only controls failing the pods
+		assertAppState(t, app, ApplicationStates().Failed, 3*time.Second)
+		if failTasks {
+			updatedPod, err := kubeClient.Get(pod.Namespace, pod.Name)
+			assert.NilError(t, err)
+			assert.Equal(t, updatedPod.Status.Phase, v1.PodFailed)
+			assert.Equal(t, updatedPod.Status.Reason, constants.ApplicationRejectedFailure)
+		} else {
+			updatedPod, err := kubeClient.Get(pod.Namespace, pod.Name)
+			assert.NilError(t, err)
+			assert.Equal(t, updatedPod.Status.Phase, v1.PodPending)
+		}
+
+		found := false
+		for len(recorder.Events) > 0 {
+			event := <-recorder.Events
+			if strings.Contains(event, "ApplicationRejected") && strings.Contains(event, "queue root.abc not found") {
+				found = true
+			}
+		}
+		assert.Assert(t, found, "rejection event not found")
+		mgr.Stop()
+		dispatcher.Stop()
+	}
+}
+
 func TestReleaseAppAllocation(t *testing.T) {
 	context := initContextForTest()
 	ms := &mockSchedulerAPI{}
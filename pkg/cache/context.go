@@ -20,22 +20,34 @@ package cache
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"reflect"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
 	schedulingv1 "k8s.io/api/scheduling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	batchListersV1 "k8s.io/client-go/listers/batch/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
 	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/volumebinding"
@@ -52,12 +64,65 @@ import (
 	"github.com/apache/yunikorn-k8shim/pkg/log"
 	"github.com/apache/yunikorn-k8shim/pkg/plugin/predicates"
 	"github.com/apache/yunikorn-k8shim/pkg/plugin/support"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/api"
 	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
 )
 
 const registerNodeContextHandler = "RegisterNodeContextHandler"
 
+// clock is the source of the current time, it is replaced in tests to control the passing of time.
+// Timers and background goroutines read it, it is therefore stored atomically.
+var clock atomic.Pointer[func() time.Time]
+
+// timeNow returns the current time of the clock
+func timeNow() time.Time {
+	if now := clock.Load(); now != nil {
+		return (*now)()
+	}
+	return time.Now()
+}
+
+// setTimeNow replaces the clock
+// VisibleForTesting
+func setTimeNow(now func() time.Time) {
+	clock.Store(&now)
+}
+
+// volumeLookupBackoff is the initial wait before a failed volume lookup is retried
+var volumeLookupBackoff = 100 * time.Millisecond
+
+// bindThroughputWindow is the period over which bindings are counted to estimate the binding throughput
+const bindThroughputWindow = 5 * time.Minute
+
+// connectionErrorWindow is the period over which failed scheduler core calls are counted as recent errors
+const connectionErrorWindow = 5 * time.Minute
+
+// healthReportStuckThreshold is the time after which a pending task is reported as stuck in the application health report
+const healthReportStuckThreshold = 10 * time.Minute
+
+// rejectionRecord tracks the rejections of an allocation by the core within the rejection event window.
+type rejectionRecord struct {
+	first time.Time // time of the first rejection within the window
+	count int       // number of rejections since the first one
+}
+
+// ConnectionState is the state of the connection to the core scheduler API.
+type ConnectionState string
+
+const (
+	ConnectionUnknown     ConnectionState = "unknown"     // no call to the core was made yet
+	ConnectionReachable   ConnectionState = "reachable"   // the most recent call to the core succeeded
+	ConnectionUnreachable ConnectionState = "unreachable" // the most recent call to the core failed
+)
+
+// ConnectionStatus describes the health of the connection to the core scheduler API.
+type ConnectionStatus struct {
+	State        ConnectionState
+	LastSuccess  time.Time // time of the last successful call, zero if none succeeded yet
+	RecentErrors int       // number of failed calls within the error window
+}
+
 var (
 	ErrorPodNotFound  = errors.New("predicates were not run because pod was not found in cache")
 	ErrorNodeNotFound = errors.New("predicates were not run because node was not found in cache")
@@ -65,16 +130,86 @@ var (
 
 // context maintains scheduling state, like apps and apps' tasks.
 type Context struct {
-	applications   map[string]*Application        // apps
-	schedulerCache *schedulercache.SchedulerCache // external cache
-	apiProvider    client.APIProvider             // apis to interact with api-server, scheduler-core, etc
-	predManager    predicates.PredicateManager    // K8s predicates
-	pluginMode     bool                           // true if we are configured as a scheduler plugin
-	namespace      string                         // yunikorn namespace
-	configMaps     []*v1.ConfigMap                // cached yunikorn configmaps
-	lock           *locking.RWMutex               // lock
-	txnID          atomic.Uint64                  // transaction ID counter
-	klogger        klog.Logger
+	applications      map[string]*Application        // apps
+	schedulerCache    *schedulercache.SchedulerCache // external cache
+	apiProvider       client.APIProvider             // apis to interact with api-server, scheduler-core, etc
+	predManager       predicates.PredicateManager    // K8s predicates
+	pluginMode        bool                           // true if we are configured as a scheduler plugin
+	namespace         string                         // yunikorn namespace
+	configMaps        []*v1.ConfigMap                // cached yunikorn configmaps
+	configChecksum    string                         // checksum of the applied configmaps
+	allocationLimiter flowcontrol.RateLimiter        // limits new allocation requests to the core, nil if unlimited
+	nodeScorer        NodeScorer                     // scores candidate nodes for a task
+	nodeUpdates       map[string]*time.Timer         // delayed node resource updates to the core, keyed by node name
+	nodeUpdatesLock   locking.Mutex                  // lock for the delayed node resource updates
+	connStatus        ConnectionStatus               // status of the calls to the core
+	connErrors        []time.Time                    // times of the failed calls to the core within the error window
+	connLock          locking.Mutex                  // lock for the connection status
+	rejections        map[string]*rejectionRecord    // repeated allocation rejections, keyed by allocation key
+	rejectionsLock    locking.Mutex                  // lock for the allocation rejections
+	nodeFlaps         map[string][]time.Time         // readiness changes of nodes within the flap window, oldest first
+	nodeFlapsLock     locking.Mutex                  // lock for the node readiness changes
+	releasedPods      map[string]bool                // UIDs of existing pods whose task was released early
+	suspendedQueues   map[string]bool                // queues in which no application is scheduled
+	lock              *locking.RWMutex               // lock
+	txnID             atomic.Uint64                  // transaction ID counter
+	klogger           klog.Logger
+}
+
+// AppFilter selects applications, all set fields must match. An empty filter matches all applications.
+type AppFilter struct {
+	Queue string            // full queue name of the application
+	User  string            // user of the application
+	State string            // state of the application
+	Tags  map[string]string // tags which the application must have with the given value
+}
+
+// matches returns true if the application passes all conditions of the filter
+func (f AppFilter) matches(app *Application) bool {
+	if f.Queue != "" && app.GetQueue() != f.Queue {
+		return false
+	}
+	if f.User != "" && app.GetUser() != f.User {
+		return false
+	}
+	if f.State != "" && app.GetApplicationState() != f.State {
+		return false
+	}
+	if len(f.Tags) > 0 {
+		tags := app.GetTags()
+		for k, v := range f.Tags {
+			if value, ok := tags[k]; !ok || value != v {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// NodeScorer returns the placement preference score of a node for a task, a higher score is preferred
+type NodeScorer func(task *Task, nodeID string) int64
+
+// defaultNodeScorer has no preference for any node
+func defaultNodeScorer(_ *Task, _ string) int64 {
+	return 0
+}
+
+// NodeAllocationSummary is a point in time view of the resources of a node
+type NodeAllocationSummary struct {
+	Capacity          *si.Resource // schedulable resources of the node
+	Occupied          *si.Resource // resources used by pods not scheduled by YuniKorn
+	Allocated         *si.Resource // resources of the tasks bound to the node
+	PendingAllocation *si.Resource // resources of the pods with a pending allocation on the node (plugin mode)
+}
+
+// AppHealthReport bundles the diagnostic information of an application
+type AppHealthReport struct {
+	State           string            // current application state
+	TaskStates      map[string]int    // number of tasks per task state
+	PendingDuration time.Duration     // time the application waited for its first task to be bound, up to now if none is bound
+	StuckTasks      []string          // sorted IDs of the pending tasks created longer than the stuck task threshold ago
+	FailureReason   string            // reason the application failed, empty if it did not fail
+	WaitReasons     map[string]string // reason the core reported for not scheduling a pending task, by task ID
 }
 
 // NewContext create a new context for the scheduler using a default (empty) configuration
@@ -91,12 +226,29 @@ func NewContextWithBootstrapConfigMaps(apis client.APIProvider, bootstrapConfigM
 	// nodecontroller needs the cache
 	// predictor need the cache, volumebinder and informers
 	ctx := &Context{
-		applications: make(map[string]*Application),
-		apiProvider:  apis,
-		namespace:    apis.GetAPIs().GetConf().Namespace,
-		configMaps:   bootstrapConfigMaps,
-		lock:         &locking.RWMutex{},
-		klogger:      klog.NewKlogr(),
+		applications:    make(map[string]*Application),
+		apiProvider:     apis,
+		namespace:       apis.GetAPIs().GetConf().Namespace,
+		configMaps:      bootstrapConfigMaps,
+		nodeScorer:      defaultNodeScorer,
+		nodeUpdates:     make(map[string]*time.Timer),
+		rejections:      make(map[string]*rejectionRecord),
+		nodeFlaps:       make(map[string][]time.Time),
+		releasedPods:    make(map[string]bool),
+		suspendedQueues: make(map[string]bool),
+		connStatus:      ConnectionStatus{State: ConnectionUnknown},
+		lock:            &locking.RWMutex{},
+		klogger:         klog.NewKlogr(),
+	}
+	ctx.configChecksum = configMapsChecksum(bootstrapConfigMaps)
+
+	// create the allocation request rate limiter, if configured
+	if qps := apis.GetAPIs().GetConf().AllocationRequestQPS; qps > 0 {
+		burst := apis.GetAPIs().GetConf().AllocationRequestBurst
+		if burst < 1 {
+			burst = 1
+		}
+		ctx.allocationLimiter = flowcontrol.NewTokenBucketRateLimiter(float32(qps), burst)
 	}
 
 	// create the cache
@@ -126,6 +278,10 @@ func (ctx *Context) AddSchedulingEventHandlers() {
 		UpdateFn: ctx.updatePriorityClass,
 		DeleteFn: ctx.deletePriorityClass,
 	})
+	ctx.apiProvider.AddEventHandler(&client.ResourceEventHandlers{
+		Type:     client.NamespaceInformerHandlers,
+		DeleteFn: ctx.deleteNamespace,
+	})
 	ctx.apiProvider.AddEventHandler(&client.ResourceEventHandlers{
 		Type:     client.NodeInformerHandlers,
 		AddFn:    ctx.addNode,
@@ -160,10 +316,22 @@ func (ctx *Context) updateNode(_, obj interface{}) {
 }
 
 func (ctx *Context) updateNodeInternal(node *v1.Node, register bool) {
+	if schedulerconf.GetSchedulerConf().ResetReplacedNodes {
+		// release the tasks only after the replacement node is processed: registering the node waits on the
+		// dispatcher, which must not be blocked by task events queued while the context lock is held
+		releasedTasks := ctx.resetReplacedNode(node)
+		defer func() {
+			for _, taskMeta := range releasedTasks {
+				ctx.notifyTaskComplete(taskMeta.ApplicationID, taskMeta.TaskID)
+			}
+		}()
+	}
 	// update scheduler cache
 	if prevNode, adoptedPods := ctx.schedulerCache.UpdateNode(node); prevNode == nil {
 		// newly added node
 
+		ctx.checkNodeResources(node)
+
 		// if requested, register this node with the scheduler core. this is optional to allow for bulk registration
 		// during scheduler initialization.
 		if register {
@@ -199,15 +367,93 @@ func (ctx *Context) updateNodeInternal(node *v1.Node, register bool) {
 		newCapacity := common.GetNodeResource(&node.Status)
 
 		if !common.Equals(prevCapacity, newCapacity) {
+			ctx.checkNodeResources(node)
 			// update capacity
 			if capacity, occupied, ok := ctx.schedulerCache.UpdateCapacity(node.Name, newCapacity); ok {
-				if err := ctx.updateNodeResources(node, capacity, occupied); err != nil {
+				if delay := schedulerconf.GetSchedulerConf().NodeUpdateDebounce; delay > 0 {
+					ctx.delayNodeResourcesUpdate(node.Name, delay)
+				} else if err := ctx.updateNodeResources(node, capacity, occupied); err != nil {
 					log.Log(log.ShimContext).Warn("Failed to update node capacity", zap.Error(err))
 				}
 			} else {
 				log.Log(log.ShimContext).Warn("Failed to update cached node capacity", zap.String("nodeName", node.Name))
 			}
 		}
+
+		// node was cordoned and is schedulable again
+		if prevNode.Spec.Unschedulable && !node.Spec.Unschedulable {
+			log.Log(log.ShimContext).Info("Node is schedulable again", zap.String("nodeName", node.Name))
+			if schedulerconf.GetSchedulerConf().EmitNodeSchedulableEvents {
+				events.GetRecorder().Eventf(node.DeepCopy(), nil, v1.EventTypeNormal, "NodeSchedulable", "NodeSchedulable",
+					"node %s is schedulable again", node.Name)
+			}
+		}
+
+		// node changed readiness, quarantine it if it keeps flapping
+		if threshold := schedulerconf.GetSchedulerConf().NodeFlapThreshold; threshold > 0 && isNodeReady(prevNode) != isNodeReady(node) {
+			ctx.trackNodeFlap(node, threshold)
+		}
+	}
+}
+
+// trackNodeFlap records a readiness change of the node. A node that changes readiness threshold times within the
+// flap window is drained in the core, so it stops receiving allocations, and a warning event is published.
+func (ctx *Context) trackNodeFlap(node *v1.Node, threshold int) {
+	window := schedulerconf.GetSchedulerConf().NodeFlapWindow
+	now := timeNow()
+	cutoff := now.Add(-window)
+	ctx.nodeFlapsLock.Lock()
+	flaps := ctx.nodeFlaps[node.Name]
+	i := 0
+	for i < len(flaps) && flaps[i].Before(cutoff) {
+		i++
+	}
+	flaps = append(flaps[i:], now)
+	quarantine := len(flaps) >= threshold
+	if quarantine {
+		delete(ctx.nodeFlaps, node.Name)
+	} else {
+		ctx.nodeFlaps[node.Name] = flaps
+	}
+	ctx.nodeFlapsLock.Unlock()
+	if !quarantine || slices.Contains(ctx.schedulerCache.GetDrainingNodeNames(), node.Name) {
+		return
+	}
+	log.Log(log.ShimContext).Warn("Node is flapping, quarantining node",
+		zap.String("nodeName", node.Name),
+		zap.Int("readinessChanges", threshold),
+		zap.Duration("window", window))
+	if err := ctx.DrainNodes([]string{node.Name})[node.Name]; err != nil {
+		log.Log(log.ShimContext).Warn("Failed to quarantine node", zap.String("nodeName", node.Name), zap.Error(err))
+		return
+	}
+	events.GetRecorder().Eventf(node.DeepCopy(), nil, v1.EventTypeWarning, "NodeQuarantined", "NodeQuarantined",
+		"node %s changed readiness %d times within %s and is quarantined", node.Name, threshold, window)
+}
+
+// isNodeReady returns true if the node reports the ready condition as true.
+func isNodeReady(node *v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// checkNodeResources logs the allocatable resources of the node which cannot be forwarded to the core as they are,
+// and optionally publishes a warning event on the node.
+func (ctx *Context) checkNodeResources(node *v1.Node) {
+	names := common.GetUnparseableNodeResources(&node.Status)
+	if len(names) == 0 {
+		return
+	}
+	log.Log(log.ShimContext).Warn("Node has allocatable resources that cannot be converted to integer values",
+		zap.String("nodeName", node.Name),
+		zap.Strings("resources", names))
+	if schedulerconf.GetSchedulerConf().WarnOnUnparseableNodeResource {
+		events.GetRecorder().Eventf(node.DeepCopy(), nil, v1.EventTypeWarning, "UnparseableNodeResource", "UnparseableNodeResource",
+			"node %s has allocatable resources that cannot be converted to integer values: %s", node.Name, strings.Join(names, ", "))
 	}
 }
 
@@ -241,6 +487,37 @@ func (ctx *Context) addNodesWithoutRegistering(nodes []*v1.Node) {
 	}
 }
 
+// resetReplacedNode removes the cached node if the node was replaced by a new node object with the same name.
+// The pods assigned to the replaced node went away with it: they are removed from the cache instead of being
+// adopted by the new node, which is then added with no occupied resources or allocations. The tasks of the
+// YuniKorn pods on the replaced node are returned so their allocations can be released.
+func (ctx *Context) resetReplacedNode(node *v1.Node) []TaskMetadata {
+	nodeInfo := ctx.schedulerCache.GetNode(node.Name)
+	if nodeInfo == nil || nodeInfo.Node() == nil || nodeInfo.Node().UID == node.UID {
+		return nil
+	}
+	prevNode := nodeInfo.Node()
+	pods := make([]*v1.Pod, 0, len(nodeInfo.Pods))
+	for _, podInfo := range nodeInfo.Pods {
+		pods = append(pods, podInfo.Pod)
+	}
+	log.Log(log.ShimContext).Info("node was replaced, resetting node",
+		zap.String("nodeName", node.Name),
+		zap.String("previousUID", string(prevNode.UID)),
+		zap.String("UID", string(node.UID)),
+		zap.Int("assignedPods", len(pods)))
+	ctx.deleteNodeInternal(prevNode)
+	var releasedTasks []TaskMetadata
+	for _, pod := range pods {
+		if taskMeta, ok := getTaskMetadata(pod); ok {
+			releasedTasks = append(releasedTasks, taskMeta)
+		}
+		ctx.schedulerCache.RemovePod(pod)
+		delete(ctx.releasedPods, string(pod.UID))
+	}
+	return releasedTasks
+}
+
 func (ctx *Context) deleteNodeInternal(node *v1.Node) {
 	// remove node from scheduler cache
 	prevNode, orphanedPods := ctx.schedulerCache.RemoveNode(node)
@@ -248,6 +525,10 @@ func (ctx *Context) deleteNodeInternal(node *v1.Node) {
 		// nothing to do if node wasn't there
 		return
 	}
+	ctx.nodeFlapsLock.Lock()
+	delete(ctx.nodeFlaps, node.Name)
+	ctx.nodeFlapsLock.Unlock()
+	ctx.cancelNodeResourcesUpdate(node.Name)
 
 	// log the number of orphaned pods, but we shouldn't need to do any processing of them as the core will send
 	// back remove events for each of them
@@ -270,7 +551,7 @@ func (ctx *Context) AddPod(obj interface{}) {
 	ctx.UpdatePod(nil, obj)
 }
 
-func (ctx *Context) UpdatePod(_, newObj interface{}) {
+func (ctx *Context) UpdatePod(oldObj, newObj interface{}) {
 	ctx.lock.Lock()
 	defer ctx.lock.Unlock()
 
@@ -279,10 +560,94 @@ func (ctx *Context) UpdatePod(_, newObj interface{}) {
 		log.Log(log.ShimContext).Error("failed to update pod", zap.Error(err))
 		return
 	}
+	// pods are namespaced, a pod without one is malformed and would corrupt the cache keys
+	if pod.Namespace == "" {
+		log.Log(log.ShimContext).Warn("skipping pod without namespace",
+			zap.String("podName", pod.Name),
+			zap.String("podUID", string(pod.UID)))
+		return
+	}
 	if utils.GetApplicationIDFromPod(pod) == "" {
 		ctx.updateForeignPod(pod)
-	} else {
-		ctx.updateYuniKornPod(pod)
+		return
+	}
+	// a newly set deletion timestamp means the pod is going away: release the task without waiting for the delete
+	if schedulerconf.GetSchedulerConf().ReleaseTerminatingPods && pod.DeletionTimestamp != nil {
+		if oldPod, err := utils.Convert2Pod(oldObj); err == nil && oldPod.DeletionTimestamp == nil {
+			ctx.releaseTerminatingPod(pod)
+		}
+	}
+	if timeout := schedulerconf.GetSchedulerConf().ImagePullBackoffTimeout; timeout > 0 {
+		ctx.checkImagePullBackOff(pod, timeout)
+	}
+	if schedulerconf.GetSchedulerConf().AllowAppIDChange {
+		if oldPod, err := utils.Convert2Pod(oldObj); err == nil {
+			if oldAppID := utils.GetApplicationIDFromPod(oldPod); oldAppID != "" && oldAppID != utils.GetApplicationIDFromPod(pod) {
+				ctx.removeMovedTask(oldAppID, pod)
+			}
+		}
+	}
+	ctx.updateYuniKornPod(pod)
+}
+
+// checkImagePullBackOff fails the task of a bound pod which has been in an image pull back-off for longer than
+// the timeout, this releases the allocation of the task.
+func (ctx *Context) checkImagePullBackOff(pod *v1.Pod, timeout time.Duration) {
+	taskMeta, ok := getTaskMetadata(pod)
+	if !ok {
+		return
+	}
+	app := ctx.getApplication(taskMeta.ApplicationID)
+	if app == nil {
+		return
+	}
+	task, err := app.GetTask(taskMeta.TaskID)
+	if err != nil || task.GetTaskState() != TaskStates().Bound {
+		return
+	}
+	if waited := task.trackImagePullBackOff(utils.IsPodImagePullBackOff(pod), timeNow()); waited > timeout {
+		log.Log(log.ShimContext).Info("failing task of pod stuck in image pull back-off",
+			zap.String("namespace", pod.Namespace),
+			zap.String("podName", pod.Name),
+			zap.Duration("waited", waited))
+		ctx.releasedPods[string(pod.UID)] = true
+		dispatcher.Dispatch(NewFailBoundTaskEvent(taskMeta.ApplicationID, taskMeta.TaskID,
+			fmt.Sprintf("pod has been in image pull back-off for more than %s", timeout)))
+	}
+}
+
+// removeMovedTask removes the task of a pod which changed its application ID from the old application.
+// The task is added to the new application as part of the normal pod update.
+func (ctx *Context) removeMovedTask(oldAppID string, pod *v1.Pod) {
+	app := ctx.getApplication(oldAppID)
+	if app == nil {
+		return
+	}
+	taskID := string(pod.UID)
+	task, err := app.GetTask(taskID)
+	if err != nil {
+		return
+	}
+	log.Log(log.ShimContext).Info("pod changed application, moving task",
+		zap.String("namespace", pod.Namespace),
+		zap.String("podName", pod.Name),
+		zap.String("oldAppID", oldAppID),
+		zap.String("newAppID", utils.GetApplicationIDFromPod(pod)))
+	if !task.isTerminated() {
+		task.releaseAllocation()
+	}
+	app.RemoveTask(taskID)
+}
+
+func (ctx *Context) releaseTerminatingPod(pod *v1.Pod) {
+	if taskMeta, ok := getTaskMetadata(pod); ok {
+		if app := ctx.getApplication(taskMeta.ApplicationID); app != nil {
+			log.Log(log.ShimContext).Info("releasing task of terminating pod",
+				zap.String("namespace", pod.Namespace),
+				zap.String("podName", pod.Name))
+			ctx.releasedPods[string(pod.UID)] = true
+			ctx.notifyTaskComplete(taskMeta.ApplicationID, taskMeta.TaskID)
+		}
 	}
 }
 
@@ -291,12 +656,17 @@ func (ctx *Context) updateYuniKornPod(pod *v1.Pod) {
 	if utils.IsPodTerminated(pod) {
 		if taskMeta, ok := getTaskMetadata(pod); ok {
 			if app := ctx.getApplication(taskMeta.ApplicationID); app != nil {
-				ctx.notifyTaskComplete(taskMeta.ApplicationID, taskMeta.TaskID)
+				if schedulerconf.GetSchedulerConf().FailRestartNeverPods && isFailedRestartNeverPod(pod) {
+					ctx.notifyTaskFailed(pod, taskMeta.ApplicationID, taskMeta.TaskID)
+				} else {
+					ctx.notifyTaskComplete(taskMeta.ApplicationID, taskMeta.TaskID)
+				}
 			}
 		}
 
 		log.Log(log.ShimContext).Debug("Request to update terminated pod, removing from cache", zap.String("podName", pod.Name))
 		ctx.schedulerCache.RemovePod(pod)
+		delete(ctx.releasedPods, string(pod.UID))
 		return
 	}
 
@@ -307,6 +677,11 @@ func (ctx *Context) updateYuniKornPod(pod *v1.Pod) {
 }
 
 func (ctx *Context) ensureAppAndTaskCreated(pod *v1.Pod) {
+	// the task of a released pod must not be recovered from later updates of the pod
+	if ctx.releasedPods[string(pod.UID)] {
+		return
+	}
+
 	// get app metadata
 	appMeta, ok := getAppMetadata(pod)
 	if !ok {
@@ -319,6 +694,12 @@ func (ctx *Context) ensureAppAndTaskCreated(pod *v1.Pod) {
 	// add app if it doesn't already exist
 	app := ctx.getApplication(appMeta.ApplicationID)
 	if app == nil {
+		// attach the kind of workload that created the pod if configured
+		if schedulerconf.GetSchedulerConf().TagWorkloadKind {
+			if kind := getWorkloadKindFromPod(pod, ctx.getJobLister()); kind != "" {
+				appMeta.Tags[constants.AppTagWorkloadKind] = kind
+			}
+		}
 		app = ctx.addApplication(&AddApplicationRequest{
 			Metadata: appMeta,
 		})
@@ -427,12 +808,36 @@ func (ctx *Context) deleteYuniKornPod(pod *v1.Pod) {
 	defer ctx.lock.Unlock()
 	if taskMeta, ok := getTaskMetadata(pod); ok {
 		if app := ctx.getApplication(taskMeta.ApplicationID); app != nil {
+			if ctx.isSpuriousPodDelete(app, taskMeta.TaskID, pod) {
+				log.Log(log.ShimContext).Warn("ignoring delete of bound pod which still exists",
+					zap.String("namespace", pod.Namespace),
+					zap.String("podName", pod.Name))
+				return
+			}
 			ctx.notifyTaskComplete(taskMeta.ApplicationID, taskMeta.TaskID)
 		}
 	}
 
 	log.Log(log.ShimContext).Debug("removing pod from cache", zap.String("podName", pod.Name))
 	ctx.schedulerCache.RemovePod(pod)
+	delete(ctx.releasedPods, string(pod.UID))
+}
+
+// isSpuriousPodDelete returns true if pod delete verification is enabled, the task of the pod is bound and
+// the pod lister still contains the same pod. Errors from the lister are treated as a real delete.
+func (ctx *Context) isSpuriousPodDelete(app *Application, taskID string, pod *v1.Pod) bool {
+	if !schedulerconf.GetSchedulerConf().VerifyPodDeletes {
+		return false
+	}
+	task, err := app.GetTask(taskID)
+	if err != nil || task.GetTaskState() != TaskStates().Bound {
+		return false
+	}
+	existing, err := ctx.apiProvider.GetAPIs().PodInformer.Lister().Pods(pod.Namespace).Get(pod.Name)
+	if err != nil {
+		return false
+	}
+	return existing.UID == pod.UID
 }
 
 func (ctx *Context) deleteForeignPod(pod *v1.Pod) {
@@ -568,12 +973,54 @@ func (ctx *Context) addPriorityClass(obj interface{}) {
 	ctx.updatePriorityClass(nil, obj)
 }
 
-func (ctx *Context) updatePriorityClass(_, newObj interface{}) {
+func (ctx *Context) updatePriorityClass(oldObj, newObj interface{}) {
+	priorityClass := utils.Convert2PriorityClass(newObj)
+	if priorityClass == nil {
+		return
+	}
 	ctx.lock.Lock()
-	defer ctx.lock.Unlock()
-	if priorityClass := utils.Convert2PriorityClass(newObj); priorityClass != nil {
-		ctx.updatePriorityClassInternal(priorityClass)
+	ctx.updatePriorityClassInternal(priorityClass)
+	var tasks []*Task
+	if schedulerconf.GetSchedulerConf().RepropagatePCChanges && preemptionPolicyChanged(utils.Convert2PriorityClass(oldObj), priorityClass) {
+		tasks = ctx.getBoundTasksByPriorityClass(priorityClass.Name)
+	}
+	ctx.lock.Unlock()
+	// the allocations of bound tasks carry the preemption policy: update the core outside the context lock
+	for _, task := range tasks {
+		if err := task.updatePreemptionPolicy(); err != nil {
+			log.Log(log.ShimContext).Warn("failed to update allocation preemption policy",
+				zap.String("appID", task.applicationID),
+				zap.String("taskID", task.GetTaskID()),
+				zap.Error(err))
+		}
+	}
+}
+
+// preemptionPolicyChanged returns true if the preemption related settings differ between the priority classes.
+func preemptionPolicyChanged(oldPC, newPC *schedulingv1.PriorityClass) bool {
+	if oldPC == nil || newPC == nil {
+		return false
+	}
+	if oldPC.Annotations[constants.AnnotationAllowPreemption] != newPC.Annotations[constants.AnnotationAllowPreemption] {
+		return true
+	}
+	if oldPC.PreemptionPolicy == nil || newPC.PreemptionPolicy == nil {
+		return oldPC.PreemptionPolicy != newPC.PreemptionPolicy
+	}
+	return *oldPC.PreemptionPolicy != *newPC.PreemptionPolicy
+}
+
+// getBoundTasksByPriorityClass returns the bound tasks whose pod references the given priority class.
+func (ctx *Context) getBoundTasksByPriorityClass(pcName string) []*Task {
+	tasks := make([]*Task, 0)
+	for _, app := range ctx.applications {
+		for _, task := range app.GetBoundTasks() {
+			if task.GetTaskPod().Spec.PriorityClassName == pcName {
+				tasks = append(tasks, task)
+			}
+		}
 	}
+	return tasks
 }
 
 func (ctx *Context) updatePriorityClassInternal(priorityClass *schedulingv1.PriorityClass) {
@@ -600,6 +1047,53 @@ func (ctx *Context) deletePriorityClass(obj interface{}) {
 	}
 }
 
+// when a namespace is deleted, fail and remove the applications of that namespace if configured
+func (ctx *Context) deleteNamespace(obj interface{}) {
+	var namespace *v1.Namespace
+	switch t := obj.(type) {
+	case *v1.Namespace:
+		namespace = t
+	case cache.DeletedFinalStateUnknown:
+		namespace = utils.Convert2Namespace(t.Obj)
+	default:
+		log.Log(log.ShimContext).Warn("unable to convert to namespace")
+		return
+	}
+	if namespace == nil || !schedulerconf.GetSchedulerConf().CleanupOnNamespaceDelete {
+		return
+	}
+
+	// collect the applications under the lock, the core is only called after the lock is released
+	removed := make([]*Application, 0)
+	ctx.lock.Lock()
+	for appID, app := range ctx.applications {
+		if app.GetTags()[constants.AppTagNamespace] != namespace.Name {
+			continue
+		}
+		removed = append(removed, app)
+		delete(ctx.applications, appID)
+	}
+	ctx.lock.Unlock()
+
+	for _, app := range removed {
+		appID := app.GetApplicationID()
+		log.Log(log.ShimContext).Info("removing application of deleted namespace",
+			zap.String("appID", appID),
+			zap.String("namespace", namespace.Name))
+		ev := NewFailApplicationEvent(appID, fmt.Sprintf("namespace %s deleted", namespace.Name))
+		if app.canHandle(ev) {
+			if err := app.handle(ev); err != nil {
+				log.Log(log.ShimContext).Warn("failed to fail application", zap.String("appID", appID), zap.Error(err))
+			}
+		}
+		// the pods are removed with the namespace, no need to wait for the tasks to terminate
+		rr := common.CreateUpdateRequestForRemoveApplication(appID, app.partition)
+		if err := ctx.TrackSchedulerCall(ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateApplication(rr)); err != nil {
+			log.Log(log.ShimContext).Error("failed to send remove application request to core", zap.Error(err))
+		}
+	}
+}
+
 func (ctx *Context) triggerReloadConfig(index int, configMap *v1.ConfigMap) {
 	ctx.lock.Lock()
 	defer ctx.lock.Unlock()
@@ -630,9 +1124,39 @@ func (ctx *Context) triggerReloadConfig(index int, configMap *v1.ConfigMap) {
 		Config:      config,
 		ExtraConfig: extraConfig,
 	}
-	if err := ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateConfiguration(request); err != nil {
+	if err := ctx.TrackSchedulerCall(ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateConfiguration(request)); err != nil {
 		log.Log(log.ShimContext).Error("reload configuration failed", zap.Error(err))
+		return
+	}
+	// the checksum only reflects configuration the core has accepted
+	ctx.configChecksum = configMapsChecksum(ctx.configMaps)
+}
+
+// GetSchedulerConfigChecksum returns a checksum of the currently applied scheduler configuration.
+// The checksum only changes when the content of the configuration changes.
+func (ctx *Context) GetSchedulerConfigChecksum() string {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	return ctx.configChecksum
+}
+
+// configMapsChecksum calculates the checksum of the flattened configmaps, keys are sorted to make it stable
+func configMapsChecksum(configMaps []*v1.ConfigMap) string {
+	confMap := schedulerconf.FlattenConfigMaps(configMaps)
+	keys := make([]string, 0, len(confMap))
+	for k := range confMap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	hash := sha256.New()
+	for _, k := range keys {
+		// key and value are NUL separated to prevent ambiguous concatenations
+		hash.Write([]byte(k))
+		hash.Write([]byte{0})
+		hash.Write([]byte(confMap[k]))
+		hash.Write([]byte{0})
 	}
+	return fmt.Sprintf("%X", hash.Sum(nil))
 }
 
 // EventsToRegister returns the Kubernetes events that should be watched for updates which may effect predicate processing
@@ -784,62 +1308,109 @@ func (ctx *Context) bindPodVolumes(pod *v1.Pod) error {
 // this way, the core can make allocation decisions with consideration of
 // other assumed pods before they are actually bound to the node (bound is slow).
 func (ctx *Context) AssumePod(name, node string) error {
+	// the volume lookup can be retried with a backoff, it must not block the context while doing that
+	ctx.lock.RLock()
+	pod, ok := ctx.schedulerCache.GetPod(name)
+	targetNode := ctx.schedulerCache.GetNode(node)
+	ctx.lock.RUnlock()
+	if !ok || targetNode == nil {
+		return nil
+	}
+	// assume pod volumes, this will update bindings info in cache
+	// assume pod volumes before assuming the pod
+	// this will update scheduler cache with essential PV/PVC binding info
+	volumes, err := ctx.findPodVolumes(pod, targetNode.Node())
+	if err != nil {
+		return err
+	}
+
 	ctx.lock.Lock()
 	defer ctx.lock.Unlock()
-	if pod, ok := ctx.schedulerCache.GetPod(name); ok {
-		// when add assumed pod, we make a copy of the pod to avoid
-		// modifying its original reference. otherwise, it may have
-		// race when some other go-routines accessing it in parallel.
-		if targetNode := ctx.schedulerCache.GetNode(node); targetNode != nil {
-			assumedPod := pod.DeepCopy()
-			// assume pod volumes, this will update bindings info in cache
-			// assume pod volumes before assuming the pod
-			// this will update scheduler cache with essential PV/PVC binding info
-			var allBound = true
-			var err error
-			// retrieve the volume claims
-			podVolumeClaims, err := ctx.apiProvider.GetAPIs().VolumeBinder.GetPodVolumeClaims(ctx.klogger, pod)
-			if err != nil {
-				log.Log(log.ShimContext).Error("Failed to get pod volume claims",
-					zap.String("podName", assumedPod.Name),
-					zap.Error(err))
-				return err
-			}
+	// the pod or node could have been removed while the volumes were looked up
+	if pod, ok = ctx.schedulerCache.GetPod(name); !ok || ctx.schedulerCache.GetNode(node) == nil {
+		return nil
+	}
+	// when add assumed pod, we make a copy of the pod to avoid
+	// modifying its original reference. otherwise, it may have
+	// race when some other go-routines accessing it in parallel.
+	assumedPod := pod.DeepCopy()
+	allBound, err := ctx.apiProvider.GetAPIs().VolumeBinder.AssumePodVolumes(ctx.klogger, pod, node, volumes)
+	if err != nil {
+		return err
+	}
 
-			// retrieve volumes
-			volumes, reasons, err := ctx.apiProvider.GetAPIs().VolumeBinder.FindPodVolumes(ctx.klogger, pod, podVolumeClaims, targetNode.Node())
-			if err != nil {
-				log.Log(log.ShimContext).Error("Failed to find pod volumes",
-					zap.String("podName", assumedPod.Name),
-					zap.String("nodeName", assumedPod.Spec.NodeName),
-					zap.Error(err))
-				return err
-			}
-			if len(reasons) > 0 {
-				sReasons := make([]string, len(reasons))
-				for i, reason := range reasons {
-					sReasons[i] = string(reason)
-				}
-				sReason := strings.Join(sReasons, ", ")
-				err = fmt.Errorf("pod %s has conflicting volume claims: %s", pod.Name, sReason)
-				log.Log(log.ShimContext).Error("Pod has conflicting volume claims",
-					zap.String("podName", assumedPod.Name),
-					zap.String("nodeName", assumedPod.Spec.NodeName),
-					zap.Error(err))
-				return err
-			}
-			allBound, err = ctx.apiProvider.GetAPIs().VolumeBinder.AssumePodVolumes(ctx.klogger, pod, node, volumes)
-			if err != nil {
-				return err
-			}
+	// assign the node name for pod
+	assumedPod.Spec.NodeName = node
+	ctx.schedulerCache.AssumePod(assumedPod, allBound)
+	return nil
+}
+
+// findPodVolumes retrieves the volume claims of the pod and finds the matching volumes on the node.
+// Conflicting volume claims are returned as an error.
+func (ctx *Context) findPodVolumes(pod *v1.Pod, node *v1.Node) (*volumebinding.PodVolumes, error) {
+	// retrieve the volume claims
+	podVolumeClaims, err := ctx.apiProvider.GetAPIs().VolumeBinder.GetPodVolumeClaims(ctx.klogger, pod)
+	if err != nil {
+		log.Log(log.ShimContext).Error("Failed to get pod volume claims",
+			zap.String("podName", pod.Name),
+			zap.Error(err))
+		return nil, err
+	}
 
-			// assign the node name for pod
-			assumedPod.Spec.NodeName = node
-			ctx.schedulerCache.AssumePod(assumedPod, allBound)
-			return nil
+	// retrieve volumes
+	volumes, reasons, err := ctx.findPodVolumesWithRetry(pod, podVolumeClaims, node)
+	if err != nil {
+		log.Log(log.ShimContext).Error("Failed to find pod volumes",
+			zap.String("podName", pod.Name),
+			zap.String("nodeName", pod.Spec.NodeName),
+			zap.Error(err))
+		return nil, err
+	}
+	if len(reasons) > 0 {
+		sReasons := make([]string, len(reasons))
+		for i, reason := range reasons {
+			sReasons[i] = string(reason)
 		}
+		sReason := strings.Join(sReasons, ", ")
+		err = fmt.Errorf("pod %s has conflicting volume claims: %s", pod.Name, sReason)
+		log.Log(log.ShimContext).Error("Pod has conflicting volume claims",
+			zap.String("podName", pod.Name),
+			zap.String("nodeName", pod.Spec.NodeName),
+			zap.Error(err))
+		return nil, err
 	}
-	return nil
+	return volumes, nil
+}
+
+// findPodVolumesWithRetry finds the volumes of the pod on the node. Transient errors are retried, with an
+// exponential backoff, up to the configured number of times. Volume conflicts are never retried.
+// It must not be called while holding the context lock.
+func (ctx *Context) findPodVolumesWithRetry(pod *v1.Pod, claims *volumebinding.PodVolumeClaims, node *v1.Node) (*volumebinding.PodVolumes, volumebinding.ConflictReasons, error) {
+	backoff := newRetryBackoff(volumeLookupBackoff, schedulerconf.GetSchedulerConf().VolumeLookupRetries)
+	for {
+		volumes, reasons, err := ctx.apiProvider.GetAPIs().VolumeBinder.FindPodVolumes(ctx.klogger, pod, claims, node)
+		if err == nil || backoff.Steps <= 0 || !isTransientError(err) {
+			return volumes, reasons, err
+		}
+		delay := backoff.Step()
+		log.Log(log.ShimContext).Warn("Transient error finding pod volumes, retrying",
+			zap.String("podName", pod.Name),
+			zap.Duration("backoff", delay),
+			zap.Error(err))
+		time.Sleep(delay)
+	}
+}
+
+// newRetryBackoff returns the backoff for retrying a call that failed with a transient error. The wait starts at
+// the initial value and doubles with every retry, up to the given number of retries.
+func newRetryBackoff(initial time.Duration, retries int) wait.Backoff {
+	return wait.Backoff{Duration: initial, Factor: 2, Steps: retries}
+}
+
+// isTransientError returns true if the error is caused by a temporary problem talking to the API server
+func isTransientError(err error) bool {
+	return apierrors.IsTimeout(err) || apierrors.IsServerTimeout(err) || apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServiceUnavailable(err) || errors.Is(err, context.DeadlineExceeded)
 }
 
 // forget pod must be called when a pod is assumed to be running on a node,
@@ -890,7 +1461,12 @@ func (ctx *Context) StartPodAllocation(podKey string, nodeID string) bool {
 	return ctx.schedulerCache.StartPodAllocation(podKey, nodeID)
 }
 
-// inform the scheduler that the application is completed,
+// GetBindingQueueLength returns the number of in-progress pod allocations which are waiting for the bind to finish.
+func (ctx *Context) GetBindingQueueLength() int {
+	return ctx.schedulerCache.GetInProgressPodAllocationCount()
+}
+
+// inform the scheduler that the application is completed,
 // the complete state may further explained to completed_with_errors(failed) or successfully_completed,
 // either way we need to release all allocations (if exists) for this application
 func (ctx *Context) NotifyApplicationComplete(appID string) {
@@ -934,6 +1510,31 @@ func (ctx *Context) notifyTaskComplete(appID, taskID string) {
 	}
 }
 
+// isFailedRestartNeverPod returns true if the pod failed and is never restarted by the kubelet.
+func isFailedRestartNeverPod(pod *v1.Pod) bool {
+	return pod.Status.Phase == v1.PodFailed && pod.Spec.RestartPolicy == v1.RestartPolicyNever
+}
+
+// notifyTaskFailed fails the task of a pod which failed and will not be restarted. The termination reason of the
+// pod is surfaced in an event on the pod.
+func (ctx *Context) notifyTaskFailed(pod *v1.Pod, appID, taskID string) {
+	reason := utils.GetPodTerminationReason(pod)
+	log.Log(log.ShimContext).Info("pod failed and is not restarted, failing task",
+		zap.String("appID", appID),
+		zap.String("taskID", taskID),
+		zap.String("reason", reason))
+	events.GetRecorder().Eventf(pod.DeepCopy(), nil, v1.EventTypeWarning, "PodFailed", "PodFailed",
+		"Pod failed: %s", reason)
+	failEvent := NewFailTaskEvent(appID, taskID, reason)
+	if app := ctx.getApplication(appID); app != nil {
+		if task, err := app.GetTask(taskID); err == nil && task.GetTaskState() == TaskStates().Bound {
+			failEvent = NewFailBoundTaskEvent(appID, taskID, reason)
+		}
+	}
+	dispatcher.Dispatch(failEvent)
+	dispatcher.Dispatch(NewSimpleApplicationEvent(appID, AppTaskCompleted))
+}
+
 // update application tags in the AddApplicationRequest based on the namespace annotation
 // adds the following tags to the request based on annotations (if exist):
 //   - namespace.resourcequota
@@ -964,6 +1565,12 @@ func (ctx *Context) updateApplicationTags(request *AddApplicationRequest, namesp
 	if parentQueue != "" {
 		request.Metadata.Tags[constants.AppTagNamespaceParentQueue] = parentQueue
 	}
+
+	// add the namespace preemption default as an app tag
+	allowPreemption := utils.GetNameSpaceAnnotationValue(namespaceObj, constants.AnnotationAllowPreemption)
+	if allowPreemption == constants.True || allowPreemption == constants.False {
+		request.Metadata.Tags[constants.AppTagNamespaceAllowPreemption] = allowPreemption
+	}
 }
 
 // returns the namespace object from the namespace's name
@@ -1006,13 +1613,21 @@ func (ctx *Context) addApplication(request *AddApplicationRequest) *Application
 		ctx.updateApplicationTags(request, ns)
 	}
 
+	groups := request.Metadata.Groups
+	if len(groups) == 0 {
+		// the identity has no groups, use the configured defaults so group based rules still apply
+		if defaultGroups := schedulerconf.GetSchedulerConf().DefaultGroups; len(defaultGroups) > 0 {
+			groups = make([]string, len(defaultGroups))
+			copy(groups, defaultGroups)
+		}
+	}
 	app := NewApplication(
 		request.Metadata.ApplicationID,
 		request.Metadata.QueueName,
 		request.Metadata.User,
-		request.Metadata.Groups,
+		groups,
 		request.Metadata.Tags,
-		ctx.apiProvider.GetAPIs().SchedulerAPI)
+		&trackedSchedulerAPI{SchedulerAPI: ctx.apiProvider.GetAPIs().SchedulerAPI, ctx: ctx})
 	app.setTaskGroups(request.Metadata.TaskGroups)
 	app.setTaskGroupsDefinition(request.Metadata.Tags[constants.AnnotationTaskGroups])
 	app.setSchedulingParamsDefinition(request.Metadata.Tags[constants.AnnotationSchedulingPolicyParam])
@@ -1034,16 +1649,25 @@ func (ctx *Context) addApplication(request *AddApplicationRequest) *Application
 }
 
 func (ctx *Context) IsPreemptSelfAllowed(priorityClassName string) bool {
+	if allowed, ok := ctx.getPriorityClassPreemption(priorityClassName); ok {
+		return allowed
+	}
+	return true
+}
+
+// getPriorityClassPreemption returns the preemption setting of the priority class.
+// The second return value is false if the priority class does not exist or does not opt out.
+func (ctx *Context) getPriorityClassPreemption(priorityClassName string) (bool, bool) {
 	priorityClass := ctx.schedulerCache.GetPriorityClass(priorityClassName)
 	if priorityClass == nil {
-		return true
+		return true, false
 	}
 	if value, ok := priorityClass.Annotations[constants.AnnotationAllowPreemption]; ok {
 		if value == constants.False {
-			return false
+			return false, true
 		}
 	}
-	return true
+	return true, false
 }
 
 func (ctx *Context) GetApplication(appID string) *Application {
@@ -1052,6 +1676,34 @@ func (ctx *Context) GetApplication(appID string) *Application {
 	return ctx.getApplication(appID)
 }
 
+// GetApplicationByAllocationKey returns the application that owns the allocation.
+// Returns false if the allocation is not known.
+func (ctx *Context) GetApplicationByAllocationKey(allocationKey string) (*Application, bool) {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	// allocation keys are pod UIDs: the cached pod links the allocation to the application
+	pod, ok := ctx.schedulerCache.GetPod(allocationKey)
+	if !ok {
+		return nil, false
+	}
+	app := ctx.getApplication(utils.GetApplicationIDFromPod(pod))
+	if app == nil {
+		return nil, false
+	}
+	if task, err := app.GetTask(allocationKey); err != nil || task.getAllocationKey() != allocationKey {
+		return nil, false
+	}
+	return app, true
+}
+
+// getJobLister returns the lister for jobs, nil if jobs are not watched.
+func (ctx *Context) getJobLister() batchListersV1.JobLister {
+	if jobInformer := ctx.apiProvider.GetAPIs().JobInformer; jobInformer != nil {
+		return jobInformer.Lister()
+	}
+	return nil
+}
+
 func (ctx *Context) getApplication(appID string) *Application {
 	if app, ok := ctx.applications[appID]; ok {
 		return app
@@ -1071,7 +1723,7 @@ func (ctx *Context) RemoveApplication(appID string) error {
 		}
 		// send the update request to scheduler core
 		rr := common.CreateUpdateRequestForRemoveApplication(app.applicationID, app.partition)
-		if err := ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateApplication(rr); err != nil {
+		if err := ctx.TrackSchedulerCall(ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateApplication(rr)); err != nil {
 			log.Log(log.ShimContext).Error("failed to send remove application request to core", zap.Error(err))
 		}
 		delete(ctx.applications, appID)
@@ -1093,6 +1745,31 @@ func (ctx *Context) RemoveApplicationInternal(appID string) {
 	delete(ctx.applications, appID)
 }
 
+// ForceRescheduleApplication releases the asks and allocations of the tasks of the application that are waiting for
+// an allocation or are bound, and re-submits them to the core. Bound pods cannot move: their allocations are
+// re-submitted on the node they are bound to. Tasks that are being bound are not changed.
+// Applications in a terminal state are rejected.
+func (ctx *Context) ForceRescheduleApplication(appID string) error {
+	app := ctx.GetApplication(appID)
+	if app == nil {
+		return fmt.Errorf("application %s is not found in the context", appID)
+	}
+	states := ApplicationStates()
+	switch state := app.GetApplicationState(); state {
+	case states.Rejected, states.Completed, states.Killing, states.Killed, states.Failing, states.Failed:
+		return fmt.Errorf("application %s cannot be rescheduled in state %s", appID, state)
+	}
+	tasks := app.GetSchedulingTasks()
+	tasks = append(tasks, app.GetBoundTasks()...)
+	for _, task := range tasks {
+		log.Log(log.ShimContext).Info("force rescheduling task",
+			zap.String("appID", appID),
+			zap.String("taskID", task.GetTaskID()))
+		dispatcher.Dispatch(NewSimpleTaskEvent(appID, task.GetTaskID(), RescheduleTask))
+	}
+	return nil
+}
+
 // this implements ApplicationManagementProtocol
 func (ctx *Context) AddTask(request *AddTaskRequest) *Task {
 	ctx.lock.Lock()
@@ -1121,11 +1798,29 @@ func (ctx *Context) addTask(request *AddTaskRequest) *Task {
 				}
 			}
 			task := NewFromTaskMeta(request.Metadata.TaskID, app, ctx, request.Metadata, originator)
+			// a pod that is already assigned or running was scheduled before, the task is recovered
+			if pod := request.Metadata.Pod; utils.IsAssignedPod(pod) || utils.IsPodRunning(pod) {
+				task.setOrigin(TaskOriginRecovered)
+			}
+			if exceeded := ctx.exceedsMaxPodResource(task); len(exceeded) > 0 {
+				task.failOnCreate(fmt.Sprintf("pod request exceeds the maximum allowed for resource(s) %s",
+					strings.Join(exceeded, ", ")), "PodResourceExceeded")
+			} else if ctx.exceedsNodeCapacity(task) {
+				task.failOnCreate("unschedulable: exceeds node capacity", "PodExceedsNodeCapacity")
+			} else if ctx.violatesAntiAffinity(task) {
+				task.failOnCreate("unschedulable: required pod anti-affinity cannot be satisfied on any node",
+					"PodAntiAffinityUnsatisfiable")
+			} else if err := ctx.invalidTaskGroupMinMember(task); err != nil {
+				task.failOnCreate(err.Error(), "InvalidTaskGroupMinMember")
+			}
 			app.addTask(task)
 			log.Log(log.ShimContext).Info("task added",
 				zap.String("appID", app.applicationID),
 				zap.String("taskID", task.taskID),
 				zap.String("taskState", task.GetTaskState()))
+			if schedulerconf.GetSchedulerConf().AnnotateEstimatedWait && task.GetOrigin() == TaskOriginNew {
+				ctx.annotateEstimatedWait(app, task)
+			}
 			if originator {
 				if app.GetOriginatingTask() != nil {
 					log.Log(log.ShimContext).Error("Inconsistent state - found another originator task for an application",
@@ -1143,6 +1838,123 @@ func (ctx *Context) addTask(request *AddTaskRequest) *Task {
 	return nil
 }
 
+// exceedsMaxPodResource returns the resources for which the request of a new task exceeds the configured
+// maximum pod resource. Placeholders and pods that are already running are never checked.
+func (ctx *Context) exceedsMaxPodResource(task *Task) []string {
+	maxPodResource := schedulerconf.GetSchedulerConf().MaxPodResource
+	if len(maxPodResource) == 0 || task.placeholder || task.GetTaskState() != TaskStates().New ||
+		utils.PodAlreadyBound(task.pod) {
+		return nil
+	}
+	return common.ExceedsLimit(task.resource, common.GetResource(maxPodResource))
+}
+
+// invalidTaskGroupMinMember returns an error if the task group min member annotation of a new task is malformed.
+// Pods that are already running are never checked.
+func (ctx *Context) invalidTaskGroupMinMember(task *Task) error {
+	if task.GetTaskState() != TaskStates().New || utils.PodAlreadyBound(task.pod) {
+		return nil
+	}
+	_, err := common.GetTaskGroupMinMember(task.pod)
+	return err
+}
+
+// exceedsNodeCapacity returns true if the request of a new task does not fit the allocatable resources of
+// any known node. Placeholders, pods that are already running and clusters without nodes are never checked.
+func (ctx *Context) exceedsNodeCapacity(task *Task) bool {
+	if !schedulerconf.GetSchedulerConf().FailUnschedulableOversizedPods || task.placeholder ||
+		task.GetTaskState() != TaskStates().New || utils.PodAlreadyBound(task.pod) {
+		return false
+	}
+	ctx.schedulerCache.LockForReads()
+	defer ctx.schedulerCache.UnlockForReads()
+	nodes := ctx.schedulerCache.GetNodesInfo()
+	if len(nodes) == 0 {
+		return false
+	}
+	for _, nodeInfo := range nodes {
+		node := nodeInfo.Node()
+		if node == nil {
+			continue
+		}
+		if !exceedsCapacity(task.resource, common.GetNodeResource(&node.Status)) {
+			return false
+		}
+	}
+	return true
+}
+
+// exceedsCapacity returns true if a resource of the request is larger than the capacity for that resource. A resource
+// that the capacity does not list counts as zero capacity. The pod count is not checked.
+func exceedsCapacity(request, capacity *si.Resource) bool {
+	if request == nil {
+		return false
+	}
+	for name, quantity := range request.Resources {
+		if name == string(v1.ResourcePods) {
+			continue
+		}
+		if quantity.GetValue() > capacity.GetResources()[name].GetValue() {
+			return true
+		}
+	}
+	return false
+}
+
+// violatesAntiAffinity returns true if the required pod anti-affinity of a new task cannot be satisfied by any of the
+// known nodes. Only terms using the hostname topology key are checked, other topology domains cannot be evaluated
+// reliably from the shim and are ignored.
+func (ctx *Context) violatesAntiAffinity(task *Task) bool {
+	if !schedulerconf.GetSchedulerConf().EnforceAntiAffinityLocally || task.placeholder ||
+		task.GetTaskState() != TaskStates().New || utils.PodAlreadyBound(task.pod) {
+		return false
+	}
+	pod := task.pod
+	if pod.Spec.Affinity == nil || pod.Spec.Affinity.PodAntiAffinity == nil {
+		return false
+	}
+	terms := pod.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	ctx.schedulerCache.LockForReads()
+	defer ctx.schedulerCache.UnlockForReads()
+	nodes := ctx.schedulerCache.GetNodesInfo()
+	if len(terms) == 0 || len(nodes) == 0 {
+		return false
+	}
+	for _, nodeInfo := range nodes {
+		if nodeInfo.Node() == nil || !antiAffinityConflict(pod, terms, nodeInfo) {
+			return false
+		}
+	}
+	return true
+}
+
+// antiAffinityConflict returns true if a pod on the node matches one of the hostname scoped anti-affinity terms.
+func antiAffinityConflict(pod *v1.Pod, terms []v1.PodAffinityTerm, nodeInfo *framework.NodeInfo) bool {
+	for _, term := range terms {
+		if term.TopologyKey != v1.LabelHostname {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(term.LabelSelector)
+		if err != nil {
+			continue
+		}
+		namespaces := term.Namespaces
+		if len(namespaces) == 0 {
+			namespaces = []string{pod.Namespace}
+		}
+		for _, podInfo := range nodeInfo.Pods {
+			existing := podInfo.Pod
+			if existing.UID == pod.UID || !slices.Contains(namespaces, existing.Namespace) {
+				continue
+			}
+			if selector.Matches(labels.Set(existing.Labels)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (ctx *Context) RemoveTask(appID, taskID string) {
 	ctx.lock.RLock()
 	defer ctx.lock.RUnlock()
@@ -1185,6 +1997,657 @@ func (ctx *Context) GetAllApplications() []*Application {
 	return apps
 }
 
+// GetApplications returns the applications which match the filter
+func (ctx *Context) GetApplications(filter AppFilter) []*Application {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	apps := make([]*Application, 0)
+	for _, app := range ctx.applications {
+		if filter.matches(app) {
+			apps = append(apps, app)
+		}
+	}
+	return apps
+}
+
+// GetApplicationTasksByNode returns the bound tasks of an application grouped by node.
+// Returns nil if the application is not found.
+func (ctx *Context) GetApplicationTasksByNode(appID string) map[string][]*Task {
+	app := ctx.GetApplication(appID)
+	if app == nil {
+		return nil
+	}
+	return app.GetTasksByNode()
+}
+
+// GetApplicationLastActivity returns the last time any task of the application changed state.
+// Returns a zero time if the application is not found or none of its tasks has changed state.
+func (ctx *Context) GetApplicationLastActivity(appID string) time.Time {
+	app := ctx.GetApplication(appID)
+	if app == nil {
+		return time.Time{}
+	}
+	return app.GetLastActivity()
+}
+
+// SetApplicationPaused pauses or resumes the scheduling of the application.
+// Returns an error if the application is not found.
+func (ctx *Context) SetApplicationPaused(appID string, paused bool) error {
+	app := ctx.GetApplication(appID)
+	if app == nil {
+		return fmt.Errorf("application %s is not found", appID)
+	}
+	log.Log(log.ShimContext).Info("setting application pause",
+		zap.String("appID", appID),
+		zap.Bool("paused", paused))
+	app.SetPaused(paused)
+	return nil
+}
+
+// SetQueueSuspended suspends or resumes the scheduling of the applications in the queue and its child queues.
+func (ctx *Context) SetQueueSuspended(queue string, suspended bool) {
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+	log.Log(log.ShimContext).Info("setting queue suspension",
+		zap.String("queue", queue),
+		zap.Bool("suspended", suspended))
+	if suspended {
+		ctx.suspendedQueues[queue] = true
+	} else {
+		delete(ctx.suspendedQueues, queue)
+	}
+}
+
+// IsQueueSuspended returns true if the queue or one of its parent queues is suspended.
+func (ctx *Context) IsQueueSuspended(queue string) bool {
+	return ctx.getSuspendedQueue(queue) != ""
+}
+
+// getSuspendedQueue returns the queue, or the closest parent of the queue, that is suspended.
+// Returns an empty string if neither the queue nor a parent is suspended.
+func (ctx *Context) getSuspendedQueue(queue string) string {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	for name := queue; name != ""; {
+		if ctx.suspendedQueues[name] {
+			return name
+		}
+		idx := strings.LastIndex(name, ".")
+		if idx < 0 {
+			break
+		}
+		name = name[:idx]
+	}
+	return ""
+}
+
+// GetApplicationScheduleAttempts returns the number of times the application was scheduled with tasks waiting for an
+// allocation since a task was last bound.
+// Returns 0 if the application is not found.
+func (ctx *Context) GetApplicationScheduleAttempts(appID string) int64 {
+	app := ctx.GetApplication(appID)
+	if app == nil {
+		return 0
+	}
+	return app.GetScheduleAttempts()
+}
+
+// GetApplicationScheduleReadiness reports whether the application is eligible for scheduling its tasks: the
+// application is not paused, its queue is not suspended and, for gang applications, the gang is gathered.
+// If the application is not ready the reason explains what the application is waiting for.
+func (ctx *Context) GetApplicationScheduleReadiness(appID string) (ready bool, reason string) {
+	app := ctx.GetApplication(appID)
+	if app == nil {
+		return false, fmt.Sprintf("application %s is not found", appID)
+	}
+	if app.IsPaused() {
+		return false, "application is paused"
+	}
+	if queue := ctx.getSuspendedQueue(app.GetQueue()); queue != "" {
+		return false, fmt.Sprintf("queue %s is suspended", queue)
+	}
+	states := ApplicationStates()
+	switch state := app.GetApplicationState(); state {
+	case states.Running:
+		return true, ""
+	case states.New, states.Submitted:
+		return false, "application is not accepted by the scheduler yet"
+	case states.Accepted, states.Reserving:
+		if len(app.getTaskGroups()) > 0 {
+			return false, "waiting for the gang to be gathered"
+		}
+		return false, "application is not running yet"
+	case states.Resuming:
+		return false, "waiting for the unused placeholders to be released"
+	default:
+		return false, fmt.Sprintf("application is in state %s", state)
+	}
+}
+
+// GetApplicationBindThroughput returns the number of tasks of the application bound per second during the window.
+// Returns 0 if the application is not found.
+func (ctx *Context) GetApplicationBindThroughput(appID string, window time.Duration) float64 {
+	if app := ctx.GetApplication(appID); app != nil {
+		return app.GetBindThroughput(window)
+	}
+	return 0
+}
+
+// GetPreemptionVictims returns the tasks of the application that were preempted by the core, oldest first. The
+// victims are recorded when the core releases them and are kept after the tasks are removed from the application.
+// The core does not report the preemptor in the release.
+// Returns nil if the application is not found.
+func (ctx *Context) GetPreemptionVictims(appID string) []*Task {
+	if app := ctx.GetApplication(appID); app != nil {
+		return app.GetPreemptedTasks()
+	}
+	return nil
+}
+
+// GetApplicationFirstScheduleLatency returns the time between the submission of the application and the binding
+// of its first task. The boolean is false if the application is not found or no task has been bound yet.
+func (ctx *Context) GetApplicationFirstScheduleLatency(appID string) (time.Duration, bool) {
+	if app := ctx.GetApplication(appID); app != nil {
+		return app.GetFirstScheduleLatency()
+	}
+	return 0, false
+}
+
+// GetApplicationHealthReport returns the diagnostic information of the application.
+// Returns an error if the application is not found.
+func (ctx *Context) GetApplicationHealthReport(appID string) (AppHealthReport, error) {
+	app := ctx.GetApplication(appID)
+	if app == nil {
+		return AppHealthReport{}, fmt.Errorf("application %s is not found in the context", appID)
+	}
+	now := timeNow()
+	report := AppHealthReport{
+		State:         app.GetApplicationState(),
+		TaskStates:    make(map[string]int),
+		StuckTasks:    make([]string, 0),
+		FailureReason: app.GetFailureReason(),
+		WaitReasons:   make(map[string]string),
+	}
+	if latency, ok := app.GetFirstScheduleLatency(); ok {
+		report.PendingDuration = latency
+	} else {
+		report.PendingDuration = now.Sub(app.GetSubmissionTime())
+	}
+	for _, task := range app.GetAllTasks() {
+		report.TaskStates[task.GetTaskState()]++
+	}
+	cutoff := now.Add(-healthReportStuckThreshold)
+	for _, task := range app.GetPendingTasks() {
+		if task.createTime.Before(cutoff) {
+			report.StuckTasks = append(report.StuckTasks, task.GetTaskID())
+		}
+		if reason := task.GetWaitReason(); reason != "" {
+			report.WaitReasons[task.GetTaskID()] = reason
+		}
+	}
+	sort.Strings(report.StuckTasks)
+	return report, nil
+}
+
+// GetCachedPodsForApp returns copies of the pods in the scheduler cache that belong to the application.
+func (ctx *Context) GetCachedPodsForApp(appID string) []*v1.Pod {
+	return ctx.schedulerCache.GetPodsForApplication(appID)
+}
+
+// GetApplicationTaskGroups returns the distinct task group names of the tasks of the application.
+// Returns nil if the application is not found.
+func (ctx *Context) GetApplicationTaskGroups(appID string) []string {
+	if app := ctx.GetApplication(appID); app != nil {
+		return app.GetTaskGroups()
+	}
+	return nil
+}
+
+// GetApplicationResourceHistory returns the samples of the resources allocated to the application.
+// Returns nil if the application is not found.
+func (ctx *Context) GetApplicationResourceHistory(appID string) []ResourceSample {
+	if app := ctx.GetApplication(appID); app != nil {
+		return app.GetResourceHistory()
+	}
+	return nil
+}
+
+// GetApplicationOriginPod returns the namespace and name of the first pod that was added to the application.
+// Empty strings are returned if the application is not found or has no pods.
+func (ctx *Context) GetApplicationOriginPod(appID string) (namespace, name string) {
+	app := ctx.GetApplication(appID)
+	if app == nil {
+		return "", ""
+	}
+	return app.GetOriginPod()
+}
+
+// GetNodeAllocationSummary returns the capacity, occupied, allocated and pending allocation resources of a node.
+// Returns false if the node is not found.
+func (ctx *Context) GetNodeAllocationSummary(nodeID string) (NodeAllocationSummary, bool) {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	capacity, occupied, ok := ctx.schedulerCache.SnapshotResources(nodeID)
+	if !ok {
+		return NodeAllocationSummary{}, false
+	}
+	allocated := common.NewResourceBuilder().Build()
+	for _, app := range ctx.applications {
+		for _, task := range app.GetBoundTasks() {
+			if task.getNodeName() == nodeID {
+				allocated = common.Add(allocated, task.resource)
+			}
+		}
+	}
+	return NodeAllocationSummary{
+		Capacity:          capacity,
+		Occupied:          occupied,
+		Allocated:         allocated,
+		PendingAllocation: ctx.schedulerCache.GetPendingAllocationResource(nodeID),
+	}, true
+}
+
+// GetNodeTaskDensity returns the number of bound tasks per CPU core of the capacity for each node.
+// Nodes without CPU capacity are not included.
+func (ctx *Context) GetNodeTaskDensity() map[string]float64 {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	taskCount := make(map[string]int)
+	for _, app := range ctx.applications {
+		for _, task := range app.GetBoundTasks() {
+			taskCount[task.getNodeName()]++
+		}
+	}
+	density := make(map[string]float64)
+	for _, nodeName := range ctx.schedulerCache.GetNodeNames() {
+		capacity, _, ok := ctx.schedulerCache.SnapshotResources(nodeName)
+		if !ok || capacity == nil {
+			continue
+		}
+		cpu := capacity.Resources[siCommon.CPU].GetValue()
+		if cpu <= 0 {
+			continue
+		}
+		density[nodeName] = float64(taskCount[nodeName]) / (float64(cpu) / 1000)
+	}
+	return density
+}
+
+// GetNodeAvailablePodSlots estimates how many more pods of the average size fit on the node. The average pod size
+// is computed from the requests of all pods assigned to nodes in the cache. Only resources the node reports a
+// capacity for limit the estimate. The boolean is false if the node is not found or no pods are assigned to nodes.
+func (ctx *Context) GetNodeAvailablePodSlots(nodeID string) (int, bool) {
+	// the node and pod details must be read with a stable view into the cache
+	ctx.schedulerCache.LockForReads()
+	defer ctx.schedulerCache.UnlockForReads()
+	nodeInfo := ctx.schedulerCache.GetNodesInfoMap()[nodeID]
+	if nodeInfo == nil || nodeInfo.Node() == nil {
+		return 0, false
+	}
+	total := common.NewResourceBuilder().Build()
+	count := int64(0)
+	for _, info := range ctx.schedulerCache.GetNodesInfo() {
+		for _, podInfo := range info.Pods {
+			total = common.Add(total, common.GetPodResource(podInfo.Pod))
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, false
+	}
+	used := common.NewResourceBuilder().Build()
+	for _, podInfo := range nodeInfo.Pods {
+		used = common.Add(used, common.GetPodResource(podInfo.Pod))
+	}
+	capacity := common.GetNodeResource(&nodeInfo.Node().Status)
+	slots := int64(-1)
+	for name, quantity := range total.Resources {
+		available, ok := capacity.Resources[name]
+		average := quantity.GetValue() / count
+		if !ok || average <= 0 {
+			continue
+		}
+		fit := (available.GetValue() - used.Resources[name].GetValue()) / average
+		if fit < 0 {
+			fit = 0
+		}
+		if slots < 0 || fit < slots {
+			slots = fit
+		}
+	}
+	if slots < 0 {
+		return 0, false
+	}
+	return int(slots), true
+}
+
+// GetTaskBindFailureReason returns the reason the last bind of a task failed.
+// Returns an empty string if the task is not found or no bind has failed.
+func (ctx *Context) GetTaskBindFailureReason(appID, taskID string) string {
+	if task := ctx.getTask(appID, taskID); task != nil {
+		return task.GetBindFailureReason()
+	}
+	return ""
+}
+
+// GetTaskSchedulingCycleCount returns the number of application scheduling cycles that considered the task.
+// Returns 0 if the task is not found.
+func (ctx *Context) GetTaskSchedulingCycleCount(appID, taskID string) int {
+	if task := ctx.getTask(appID, taskID); task != nil {
+		return task.GetSchedulingCycles()
+	}
+	return 0
+}
+
+// GetTaskWaitReason returns the most recent reason the core reported for not scheduling the task.
+// Returns an empty string if the task is not found or no reason was reported.
+func (ctx *Context) GetTaskWaitReason(appID, taskID string) string {
+	if task := ctx.getTask(appID, taskID); task != nil {
+		return task.GetWaitReason()
+	}
+	return ""
+}
+
+// GetTaskCreationToBindLatency returns the time between the creation and the binding of the task.
+// The boolean is false if the task is not found or not bound.
+func (ctx *Context) GetTaskCreationToBindLatency(appID, taskID string) (time.Duration, bool) {
+	if task := ctx.getTask(appID, taskID); task != nil {
+		return task.GetCreationToBindLatency()
+	}
+	return 0, false
+}
+
+// GetTaskResource returns the resource of a task as it is requested from the core.
+// Returns an error if the task is not found.
+func (ctx *Context) GetTaskResource(appID, taskID string) (*si.Resource, error) {
+	task := ctx.getTask(appID, taskID)
+	if task == nil {
+		return nil, fmt.Errorf("task %s of application %s is not found in the context", taskID, appID)
+	}
+	return task.getResource(), nil
+}
+
+// GetApplicationTaskTimeline returns the state transitions of all tasks of an application ordered by time.
+// Returns nil if the application is not found.
+func (ctx *Context) GetApplicationTaskTimeline(appID string) []TaskTransition {
+	app := ctx.GetApplication(appID)
+	if app == nil {
+		return nil
+	}
+	timeline := make([]TaskTransition, 0)
+	for _, task := range app.GetAllTasks() {
+		timeline = append(timeline, task.GetTransitions()...)
+	}
+	// transitions of one task are already ordered, a stable sort keeps that order for equal timestamps
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].Time.Before(timeline[j].Time)
+	})
+	return timeline
+}
+
+// publishBindingDecision publishes an event on the pod with the node the task was bound to and the score of that node.
+func (ctx *Context) publishBindingDecision(task *Task, pod *v1.Pod, nodeID string) {
+	ctx.lock.RLock()
+	scorer := ctx.nodeScorer
+	ctx.lock.RUnlock()
+	events.GetRecorder().Eventf(pod, nil, v1.EventTypeNormal, "BindingDecision", "BindingDecision",
+		"Pod bound to node %s with score %d", nodeID, scorer(task, nodeID))
+}
+
+// SetNodeScorer sets the function used to score candidate nodes for a task.
+// Setting nil restores the default scorer which scores all nodes zero.
+func (ctx *Context) SetNodeScorer(fn NodeScorer) {
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+	if fn == nil {
+		fn = defaultNodeScorer
+	}
+	ctx.nodeScorer = fn
+}
+
+// ScoreNodesForTask returns the score of each known node for the task, keyed by node name.
+// Returns nil if the task is not found.
+func (ctx *Context) ScoreNodesForTask(appID, taskID string) map[string]int64 {
+	task := ctx.getTask(appID, taskID)
+	if task == nil {
+		return nil
+	}
+	ctx.lock.RLock()
+	scorer := ctx.nodeScorer
+	ctx.lock.RUnlock()
+	scores := make(map[string]int64)
+	for _, nodeID := range ctx.schedulerCache.GetNodeNames() {
+		scores[nodeID] = scorer(task, nodeID)
+	}
+	return scores
+}
+
+// GetPendingTasksSortedByPriority returns the pending tasks of all applications: the tasks in the Pending state and
+// the tasks waiting for an allocation in the Scheduling state. Tasks are sorted by priority, highest first, and tasks
+// with the same priority by submission time, oldest first.
+func (ctx *Context) GetPendingTasksSortedByPriority() []*Task {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	tasks := make([]*Task, 0)
+	for _, app := range ctx.applications {
+		tasks = append(tasks, app.GetPendingTasks()...)
+		tasks = append(tasks, app.GetSchedulingTasks()...)
+	}
+	priorities := make(map[*Task]int32, len(tasks))
+	for _, task := range tasks {
+		priorities[task] = task.getPriority()
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		l := tasks[i]
+		r := tasks[j]
+		if priorities[l] != priorities[r] {
+			return priorities[l] > priorities[r]
+		}
+		return l.createTime.Before(r.createTime)
+	})
+	return tasks
+}
+
+// EstimateApplicationWait estimates how long the application waits to be scheduled. The estimate is based on
+// the number of unscheduled tasks in the queue of the application and the binding throughput of the recent past.
+// Returns false if the application is not found or if there were no recent bindings to base the estimate on.
+func (ctx *Context) EstimateApplicationWait(appID string) (time.Duration, bool) {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	app := ctx.getApplication(appID)
+	if app == nil {
+		return 0, false
+	}
+	return ctx.estimateApplicationWait(app)
+}
+
+func (ctx *Context) estimateApplicationWait(app *Application) (time.Duration, bool) {
+	// the binding throughput of all applications, taken from the task transitions
+	throughput := 0.0
+	for _, other := range ctx.applications {
+		throughput += other.GetBindThroughput(bindThroughputWindow)
+	}
+	if throughput == 0 {
+		return 0, false
+	}
+	queue := app.GetQueue()
+	depth := 0
+	for _, other := range ctx.applications {
+		if other.GetQueue() != queue {
+			continue
+		}
+		for _, task := range other.GetNonTerminatedTasks() {
+			switch task.GetTaskState() {
+			case TaskStates().New, TaskStates().Pending, TaskStates().Scheduling:
+				depth++
+			}
+		}
+	}
+	wait := time.Duration(float64(depth) / throughput * float64(time.Second))
+	return wait.Round(time.Second), true
+}
+
+// annotateEstimatedWait annotates the first pod of the application with the estimated wait time.
+// Called with the context lock held: the pod is patched asynchronously.
+func (ctx *Context) annotateEstimatedWait(app *Application, task *Task) {
+	pod := task.GetTaskPod()
+	if namespace, name := app.GetOriginPod(); pod.Namespace != namespace || pod.Name != name {
+		return
+	}
+	wait, ok := ctx.estimateApplicationWait(app)
+	if !ok {
+		return
+	}
+	go func() {
+		if err := ctx.apiProvider.GetAPIs().KubeClient.PatchPodAnnotations(pod, map[string]string{
+			constants.AnnotationEstimatedWait: wait.String(),
+		}); err != nil {
+			log.Log(log.ShimContext).Warn("failed to annotate pod with estimated wait",
+				zap.String("podName", pod.Name),
+				zap.Stringer("wait", wait),
+				zap.Error(err))
+		}
+	}()
+}
+
+// GetApplicationsNeedingRecovery returns the applications with tasks whose pods are already assigned to a node,
+// but for which the allocation has not been confirmed by the core yet.
+func (ctx *Context) GetApplicationsNeedingRecovery() []*Application {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	apps := make([]*Application, 0)
+	for _, app := range ctx.applications {
+		for _, task := range app.GetNonTerminatedTasks() {
+			if task.GetTaskState() != TaskStates().Bound && utils.IsAssignedPod(task.GetTaskPod()) {
+				apps = append(apps, app)
+				break
+			}
+		}
+	}
+	return apps
+}
+
+// GetQueues returns the sorted, distinct names of the queues referenced by the applications.
+func (ctx *Context) GetQueues() []string {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	seen := make(map[string]bool)
+	queues := make([]string, 0)
+	for _, app := range ctx.applications {
+		if queue := app.GetQueue(); queue != "" && !seen[queue] {
+			seen[queue] = true
+			queues = append(queues, queue)
+		}
+	}
+	sort.Strings(queues)
+	return queues
+}
+
+// GetApplicationsCreatedBetween returns the applications submitted at or after start and before end, sorted by
+// submission time.
+func (ctx *Context) GetApplicationsCreatedBetween(start, end time.Time) []*Application {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	apps := make([]*Application, 0)
+	for _, app := range ctx.applications {
+		if submitted := app.GetSubmissionTime(); !submitted.Before(start) && submitted.Before(end) {
+			apps = append(apps, app)
+		}
+	}
+	sort.Slice(apps, func(i, j int) bool {
+		return apps[i].GetSubmissionTime().Before(apps[j].GetSubmissionTime())
+	})
+	return apps
+}
+
+// GetApplicationsOnNode returns the applications that have at least one bound task on the node.
+func (ctx *Context) GetApplicationsOnNode(nodeID string) []*Application {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	apps := make([]*Application, 0)
+	for _, app := range ctx.applications {
+		for _, task := range app.GetBoundTasks() {
+			if task.getNodeName() == nodeID {
+				apps = append(apps, app)
+				break
+			}
+		}
+	}
+	return apps
+}
+
+// GetTasksByPriorityClass returns the non-terminated tasks whose pod references the given priority class.
+func (ctx *Context) GetTasksByPriorityClass(pcName string) []*Task {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	tasks := make([]*Task, 0)
+	for _, app := range ctx.applications {
+		for _, task := range app.GetNonTerminatedTasks() {
+			if task.GetTaskPod().Spec.PriorityClassName == pcName {
+				tasks = append(tasks, task)
+			}
+		}
+	}
+	return tasks
+}
+
+// GetApplicationsWithStuckTasks returns the pending tasks which were created longer than the threshold ago,
+// grouped by application ID. Applications without such tasks are not included.
+func (ctx *Context) GetApplicationsWithStuckTasks(threshold time.Duration) map[string][]*Task {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	cutoff := timeNow().Add(-threshold)
+	stuck := make(map[string][]*Task)
+	for appID, app := range ctx.applications {
+		for _, task := range app.GetPendingTasks() {
+			if task.createTime.Before(cutoff) {
+				stuck[appID] = append(stuck[appID], task)
+			}
+		}
+	}
+	return stuck
+}
+
+// GetTasksPendingLongerThan returns the pending tasks of the applications in the queue which were created
+// longer than the duration ago, sorted by creation time, oldest first.
+func (ctx *Context) GetTasksPendingLongerThan(queue string, d time.Duration) []*Task {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	cutoff := timeNow().Add(-d)
+	tasks := make([]*Task, 0)
+	for _, app := range ctx.applications {
+		if app.GetQueue() != queue {
+			continue
+		}
+		for _, task := range app.GetPendingTasks() {
+			if task.createTime.Before(cutoff) {
+				tasks = append(tasks, task)
+			}
+		}
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].createTime.Before(tasks[j].createTime)
+	})
+	return tasks
+}
+
+// GetTasksWaitingOnVolumes returns the tasks which have been assumed on a node but for which not all
+// pod volumes are bound yet.
+func (ctx *Context) GetTasksWaitingOnVolumes() []*Task {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	tasks := make([]*Task, 0)
+	for _, app := range ctx.applications {
+		for _, task := range app.GetNonTerminatedTasks() {
+			podKey := task.GetTaskID()
+			if ctx.schedulerCache.IsAssumedPod(podKey) && !ctx.schedulerCache.ArePodVolumesAllBound(podKey) {
+				tasks = append(tasks, task)
+			}
+		}
+	}
+	return tasks
+}
+
 func (ctx *Context) PublishEvents(eventRecords []*si.EventRecord) {
 	if len(eventRecords) > 0 {
 		for _, record := range eventRecords {
@@ -1193,6 +2656,10 @@ func (ctx *Context) PublishEvents(eventRecords []*si.EventRecord) {
 				appID := record.ReferenceID
 				taskID := record.ObjectID
 				if task := ctx.getTask(appID, taskID); task != nil {
+					// request events without a change are the core reporting why the request was not scheduled
+					if record.EventChangeType == si.EventRecord_NONE {
+						task.setWaitReason(record.Message)
+					}
 					events.GetRecorder().Eventf(task.GetTaskPod().DeepCopy(), nil,
 						v1.EventTypeNormal, "", "", record.Message)
 				} else {
@@ -1222,6 +2689,21 @@ func (ctx *Context) PublishEvents(eventRecords []*si.EventRecord) {
 				}
 				events.GetRecorder().Eventf(node.DeepCopy(), nil,
 					v1.EventTypeNormal, "Informational", "Informational", record.Message)
+			case si.EventRecord_QUEUE:
+				// queues have no kubernetes object, publish on the configured namespace if any
+				target := schedulerconf.GetSchedulerConf().QueueEventTargetNamespace
+				if target == "" {
+					continue
+				}
+				namespace := ctx.getNamespaceObject(target)
+				if namespace == nil {
+					log.Log(log.ShimContext).Warn("queue event is not published because target namespace is not found",
+						zap.String("namespace", target),
+						zap.Stringer("event", record))
+					continue
+				}
+				events.GetRecorder().Eventf(namespace.DeepCopy(), nil,
+					v1.EventTypeNormal, "QueueEvent", "QueueEvent", "queue %s: %s", record.ObjectID, record.Message)
 			}
 		}
 	}
@@ -1346,6 +2828,11 @@ func (ctx *Context) TaskEventHandler() func(obj interface{}) {
 						zap.String("event", event.GetEvent()),
 						zap.Error(err))
 				}
+				// completed tasks are otherwise only cleaned up on the next scheduling cycle of the application
+				if event.GetEvent() == CompleteTask.String() && task.GetTaskState() == TaskStates().Completed &&
+					!task.isReleasePending() && schedulerconf.GetSchedulerConf().AutoRemoveCompletedTasks {
+					task.application.RemoveTask(taskID)
+				}
 				return
 			}
 
@@ -1378,6 +2865,91 @@ func (ctx *Context) LoadConfigMaps() ([]*v1.ConfigMap, error) {
 	return []*v1.ConfigMap{defaults, config}, nil
 }
 
+// ValidateState checks the applications and tasks against the scheduler cache and returns a description of each
+// inconsistency found. An empty list is returned if the state is consistent.
+func (ctx *Context) ValidateState() []string {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	problems := make([]string, 0)
+	taskIDs := make(map[string]bool)
+	for appID, app := range ctx.applications {
+		for _, task := range app.GetAllTasks() {
+			taskID := task.GetTaskID()
+			taskIDs[taskID] = true
+			if ctx.applications[task.applicationID] == nil {
+				problems = append(problems, fmt.Sprintf("task %s of application %s references application %s which is not in the context",
+					taskID, appID, task.applicationID))
+			}
+			if task.GetTaskState() != TaskStates().Bound {
+				continue
+			}
+			if _, ok := ctx.schedulerCache.GetPod(taskID); !ok {
+				problems = append(problems, fmt.Sprintf("task %s of application %s is bound but its pod is not in the cache",
+					taskID, appID))
+			}
+			if nodeName := task.getNodeName(); ctx.schedulerCache.GetNode(nodeName) == nil {
+				problems = append(problems, fmt.Sprintf("task %s of application %s is bound to node %s which is not in the cache",
+					taskID, appID, nodeName))
+			}
+		}
+	}
+	for podKey, nodeID := range ctx.schedulerCache.GetPodAllocations() {
+		if !taskIDs[podKey] {
+			problems = append(problems, fmt.Sprintf("allocation of pod %s on node %s has no task", podKey, nodeID))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// taskListHeader is the header row of the exported task list.
+var taskListHeader = []string{"appID", "taskID", "state", "node", "queue", "user", "resource"}
+
+// ExportTaskList returns the tasks of all applications as rows of a table, for reporting. The first row is the
+// header, the task rows are sorted by application ID and task ID. Resources are formatted as sorted name=value
+// pairs separated by a semicolon.
+func (ctx *Context) ExportTaskList() [][]string {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	rows := make([][]string, 0)
+	for appID, app := range ctx.applications {
+		queue := app.GetQueue()
+		user := app.GetUser()
+		for _, task := range app.GetAllTasks() {
+			rows = append(rows, []string{appID, task.GetTaskID(), task.GetTaskState(), task.getNodeName(), queue, user,
+				formatResource(task.getResource())})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i][0] != rows[j][0] {
+			return rows[i][0] < rows[j][0]
+		}
+		return rows[i][1] < rows[j][1]
+	})
+	return append([][]string{taskListHeader}, rows...)
+}
+
+// ExportTaskListCSV writes the exported task list in CSV format.
+func (ctx *Context) ExportTaskListCSV(w io.Writer) error {
+	return csv.NewWriter(w).WriteAll(ctx.ExportTaskList())
+}
+
+func formatResource(res *si.Resource) string {
+	if res == nil {
+		return ""
+	}
+	names := make([]string, 0, len(res.Resources))
+	for name := range res.Resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%d", name, res.Resources[name].GetValue()))
+	}
+	return strings.Join(pairs, ";")
+}
+
 func (ctx *Context) GetStateDump() (string, error) {
 	log.Log(log.ShimContext).Info("State dump requested")
 
@@ -1392,6 +2964,67 @@ func (ctx *Context) GetStateDump() (string, error) {
 	return string(bytes), nil
 }
 
+// stateDumpFilePrefix is the name prefix of the periodic state dump files.
+const stateDumpFilePrefix = "yunikorn-state-"
+
+// RunPeriodicStateDump writes the state dump to a file at the configured interval until the stop channel is
+// closed. Nothing is written if no interval or path is configured. The returned channel is closed when no more
+// dumps are written.
+func (ctx *Context) RunPeriodicStateDump(stopChan <-chan struct{}) <-chan struct{} {
+	done := make(chan struct{})
+	interval := schedulerconf.GetSchedulerConf().PeriodicStateDumpInterval
+	if interval <= 0 || schedulerconf.GetSchedulerConf().PeriodicStateDumpPath == "" {
+		close(done)
+		return done
+	}
+	log.Log(log.ShimContext).Info("starting periodic state dump", zap.Duration("interval", interval))
+	go func() {
+		defer close(done)
+		wait.Until(func() {
+			if err := ctx.writeStateDump(); err != nil {
+				log.Log(log.ShimContext).Warn("failed to write periodic state dump", zap.Error(err))
+			}
+		}, interval, stopChan)
+	}()
+	return done
+}
+
+// writeStateDump writes the state dump to a new file in the configured directory and removes the oldest dump
+// files beyond the configured number of files to keep.
+func (ctx *Context) writeStateDump() error {
+	dir := schedulerconf.GetSchedulerConf().PeriodicStateDumpPath
+	keep := schedulerconf.GetSchedulerConf().PeriodicStateDumpFiles
+	dump, err := ctx.GetStateDump()
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+	// the timestamp format sorts the file names in the order they were written
+	name := stateDumpFilePrefix + timeNow().UTC().Format("20060102T150405.000000000") + ".json"
+	if err = os.WriteFile(filepath.Join(dir, name), []byte(dump), 0o600); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	dumps := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), stateDumpFilePrefix) {
+			dumps = append(dumps, entry.Name())
+		}
+	}
+	sort.Strings(dumps)
+	for i := 0; keep > 0 && i < len(dumps)-keep; i++ {
+		if err = os.Remove(filepath.Join(dir, dumps[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func isPublishableNodeEvent(event *si.EventRecord) bool {
 	// we only send node added & removed event
 	if event.Type == si.EventRecord_NODE &&
@@ -1598,10 +3231,10 @@ func (ctx *Context) registerNodes(nodes []*v1.Node) ([]*v1.Node, error) {
 	})
 	defer dispatcher.UnregisterEventHandler(handlerID, dispatcher.EventTypeNode)
 
-	if err := ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateNode(&si.NodeRequest{
+	if err := ctx.TrackSchedulerCall(ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateNode(&si.NodeRequest{
 		Nodes: nodesToRegister,
 		RmID:  schedulerconf.GetSchedulerConf().ClusterID,
-	}); err != nil {
+	})); err != nil {
 		log.Log(log.ShimContext).Error("Failed to register nodes", zap.Error(err))
 		return nil, err
 	}
@@ -1623,14 +3256,214 @@ func (ctx *Context) registerNodes(nodes []*v1.Node) ([]*v1.Node, error) {
 	return acceptedNodes, nil
 }
 
+// DrainNodes drains the given nodes in the scheduler core using a single request for all known nodes.
+// The returned map contains an error for each node that could not be drained, it is empty on success.
+func (ctx *Context) DrainNodes(nodeIDs []string) map[string]error {
+	errs := make(map[string]error)
+	nodesToDrain := make([]*si.NodeInfo, 0, len(nodeIDs))
+	for _, nodeID := range nodeIDs {
+		if ctx.schedulerCache.GetNode(nodeID) == nil {
+			errs[nodeID] = fmt.Errorf("node %s is not found in the cache", nodeID)
+			continue
+		}
+		log.Log(log.ShimContext).Info("Draining node", zap.String("name", nodeID))
+		nodesToDrain = append(nodesToDrain, &si.NodeInfo{
+			NodeID:     nodeID,
+			Action:     si.NodeInfo_DRAIN_NODE,
+			Attributes: map[string]string{},
+		})
+	}
+	if len(nodesToDrain) == 0 {
+		return errs
+	}
+	if err := ctx.TrackSchedulerCall(ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateNode(&si.NodeRequest{
+		Nodes: nodesToDrain,
+		RmID:  schedulerconf.GetSchedulerConf().ClusterID,
+	})); err != nil {
+		log.Log(log.ShimContext).Error("Failed to drain nodes", zap.Error(err))
+		for _, node := range nodesToDrain {
+			errs[node.NodeID] = err
+		}
+		return errs
+	}
+	for _, node := range nodesToDrain {
+		ctx.schedulerCache.SetNodeDraining(node.NodeID, true)
+	}
+	return errs
+}
+
+// ListDrainingNodes returns the sorted IDs of all nodes in the cache that are draining.
+func (ctx *Context) ListDrainingNodes() []string {
+	return ctx.schedulerCache.GetDrainingNodeNames()
+}
+
 func (ctx *Context) decommissionNode(node *v1.Node) error {
 	request := common.CreateUpdateRequestForDeleteOrRestoreNode(node.Name, si.NodeInfo_DECOMISSION)
-	return ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateNode(request)
+	return ctx.TrackSchedulerCall(ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateNode(request))
+}
+
+// recordAllocationRejection counts the rejections of an allocation by the core. Once the configured threshold is
+// reached within the window a single aggregated warning event is emitted on the pod, further rejections within the
+// same window are not reported again.
+func (ctx *Context) recordAllocationRejection(allocationKey string) {
+	threshold := schedulerconf.GetSchedulerConf().AllocationRejectionEventThreshold
+	if threshold <= 0 {
+		return
+	}
+	window := schedulerconf.GetSchedulerConf().AllocationRejectionEventWindow
+	ctx.rejectionsLock.Lock()
+	now := timeNow()
+	for key, record := range ctx.rejections {
+		if now.Sub(record.first) > window {
+			delete(ctx.rejections, key)
+		}
+	}
+	record, ok := ctx.rejections[allocationKey]
+	if !ok {
+		record = &rejectionRecord{first: now}
+		ctx.rejections[allocationKey] = record
+	}
+	record.count++
+	count := record.count
+	ctx.rejectionsLock.Unlock()
+	if count != threshold {
+		return
+	}
+	if pod, ok := ctx.schedulerCache.GetPod(allocationKey); ok {
+		events.GetRecorder().Eventf(pod.DeepCopy(), nil, v1.EventTypeWarning, "RepeatedAllocationRejection",
+			"RepeatedAllocationRejection", "Allocation was rejected by the scheduler %d times within %s", count, window)
+	}
+}
+
+// GetSchedulerConnectionStatus returns the status of the connection to the core scheduler API as observed by the
+// calls made from the shim.
+func (ctx *Context) GetSchedulerConnectionStatus() ConnectionStatus {
+	ctx.connLock.Lock()
+	defer ctx.connLock.Unlock()
+	ctx.connErrors = ctx.pruneConnErrors(timeNow())
+	status := ctx.connStatus
+	status.RecentErrors = len(ctx.connErrors)
+	return status
+}
+
+// TrackSchedulerCall records the outcome of a call to the core scheduler API and returns the error unchanged.
+// Every call to the core must be tracked for the connection status to be accurate.
+func (ctx *Context) TrackSchedulerCall(err error) error {
+	ctx.connLock.Lock()
+	defer ctx.connLock.Unlock()
+	now := timeNow()
+	if err != nil {
+		ctx.connStatus.State = ConnectionUnreachable
+		ctx.connErrors = append(ctx.pruneConnErrors(now), now)
+		return err
+	}
+	ctx.connStatus.State = ConnectionReachable
+	ctx.connStatus.LastSuccess = now
+	return nil
+}
+
+// trackedSchedulerAPI tracks the calls made to the core scheduler API by the applications.
+type trackedSchedulerAPI struct {
+	api.SchedulerAPI
+	ctx *Context
+}
+
+func (t *trackedSchedulerAPI) RegisterResourceManager(request *si.RegisterResourceManagerRequest, callback api.ResourceManagerCallback) (*si.RegisterResourceManagerResponse, error) {
+	response, err := t.SchedulerAPI.RegisterResourceManager(request, callback)
+	return response, t.ctx.TrackSchedulerCall(err)
+}
+
+func (t *trackedSchedulerAPI) UpdateApplication(request *si.ApplicationRequest) error {
+	return t.ctx.TrackSchedulerCall(t.SchedulerAPI.UpdateApplication(request))
+}
+
+func (t *trackedSchedulerAPI) UpdateAllocation(request *si.AllocationRequest) error {
+	return t.ctx.TrackSchedulerCall(t.SchedulerAPI.UpdateAllocation(request))
+}
+
+func (t *trackedSchedulerAPI) UpdateNode(request *si.NodeRequest) error {
+	return t.ctx.TrackSchedulerCall(t.SchedulerAPI.UpdateNode(request))
+}
+
+func (t *trackedSchedulerAPI) UpdateConfiguration(request *si.UpdateConfigurationRequest) error {
+	return t.ctx.TrackSchedulerCall(t.SchedulerAPI.UpdateConfiguration(request))
+}
+
+// pruneConnErrors removes the failed calls which are outside the error window, the connection lock must be held.
+func (ctx *Context) pruneConnErrors(now time.Time) []time.Time {
+	cutoff := now.Add(-connectionErrorWindow)
+	i := 0
+	for i < len(ctx.connErrors) && ctx.connErrors[i].Before(cutoff) {
+		i++
+	}
+	return ctx.connErrors[i:]
+}
+
+// updateAllocation sends an allocation request to the core.
+func (ctx *Context) updateAllocation(request *si.AllocationRequest) error {
+	return ctx.TrackSchedulerCall(ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateAllocation(request))
+}
+
+// tryAcceptAllocationRequest returns true if a new allocation request can be submitted to the core within the
+// configured rate limit. It never blocks: a task that is not accepted is submitted in a later scheduling cycle.
+func (ctx *Context) tryAcceptAllocationRequest() bool {
+	return ctx.allocationLimiter == nil || ctx.allocationLimiter.TryAccept()
 }
 
 func (ctx *Context) updateNodeResources(node *v1.Node, capacity *si.Resource, occupied *si.Resource) error {
 	request := common.CreateUpdateRequestForUpdatedNode(node.Name, capacity, occupied)
-	return ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateNode(request)
+	return ctx.TrackSchedulerCall(ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateNode(request))
+}
+
+// delayNodeResourcesUpdate schedules sending the cached node resources to the core after the delay.
+// Updates for a node that arrive before the delay expires are collapsed into the one scheduled update,
+// which sends the resources as cached at that time.
+func (ctx *Context) delayNodeResourcesUpdate(nodeName string, delay time.Duration) {
+	ctx.nodeUpdatesLock.Lock()
+	defer ctx.nodeUpdatesLock.Unlock()
+	if _, ok := ctx.nodeUpdates[nodeName]; ok {
+		return
+	}
+	ctx.nodeUpdates[nodeName] = time.AfterFunc(delay, func() {
+		ctx.sendDelayedNodeResources(nodeName)
+	})
+}
+
+// cancelNodeResourcesUpdate stops the delayed resource update of the node, if one is scheduled.
+func (ctx *Context) cancelNodeResourcesUpdate(nodeName string) {
+	ctx.nodeUpdatesLock.Lock()
+	defer ctx.nodeUpdatesLock.Unlock()
+	if timer, ok := ctx.nodeUpdates[nodeName]; ok {
+		timer.Stop()
+		delete(ctx.nodeUpdates, nodeName)
+	}
+}
+
+// Stop cancels the delayed node resource updates which have not been sent yet.
+// It is called when the scheduler stops.
+func (ctx *Context) Stop() {
+	ctx.nodeUpdatesLock.Lock()
+	defer ctx.nodeUpdatesLock.Unlock()
+	for nodeName, timer := range ctx.nodeUpdates {
+		timer.Stop()
+		delete(ctx.nodeUpdates, nodeName)
+	}
+}
+
+func (ctx *Context) sendDelayedNodeResources(nodeName string) {
+	ctx.nodeUpdatesLock.Lock()
+	delete(ctx.nodeUpdates, nodeName)
+	ctx.nodeUpdatesLock.Unlock()
+
+	capacity, occupied, ok := ctx.schedulerCache.SnapshotResources(nodeName)
+	if !ok {
+		log.Log(log.ShimContext).Debug("Node removed before delayed resource update", zap.String("nodeName", nodeName))
+		return
+	}
+	request := common.CreateUpdateRequestForUpdatedNode(nodeName, capacity, occupied)
+	if err := ctx.TrackSchedulerCall(ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateNode(request)); err != nil {
+		log.Log(log.ShimContext).Warn("Failed to update node capacity", zap.Error(err))
+	}
 }
 
 func (ctx *Context) enableNode(node *v1.Node) error {
@@ -1651,13 +3484,16 @@ func (ctx *Context) enableNodes(nodes []*v1.Node) error {
 	}
 
 	// enable scheduling on all nodes
-	if err := ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateNode(&si.NodeRequest{
+	if err := ctx.TrackSchedulerCall(ctx.apiProvider.GetAPIs().SchedulerAPI.UpdateNode(&si.NodeRequest{
 		Nodes: nodesToEnable,
 		RmID:  schedulerconf.GetSchedulerConf().ClusterID,
-	}); err != nil {
+	})); err != nil {
 		log.Log(log.ShimContext).Error("Failed to enable nodes", zap.Error(err))
 		return err
 	}
+	for _, node := range nodes {
+		ctx.schedulerCache.SetNodeDraining(node.Name, false)
+	}
 	return nil
 }
 
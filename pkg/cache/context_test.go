@@ -21,18 +21,26 @@ package cache
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"gotest.tools/v3/assert"
+	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	schedulingv1 "k8s.io/api/scheduling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/cache"
 	k8sEvents "k8s.io/client-go/tools/events"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/volumebinding"
 
 	schedulercache "github.com/apache/yunikorn-k8shim/pkg/cache/external"
@@ -44,6 +52,7 @@ import (
 	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
 	"github.com/apache/yunikorn-k8shim/pkg/conf"
 	"github.com/apache/yunikorn-k8shim/pkg/dispatcher"
+	"github.com/apache/yunikorn-k8shim/pkg/locking"
 	"github.com/apache/yunikorn-k8shim/pkg/log"
 	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
@@ -51,6 +60,7 @@ import (
 
 const (
 	Host1  = "HOST1"
+	Host2  = "HOST2"
 	appID1 = "app00001"
 	appID2 = "app00002"
 	appID3 = "app00003"
@@ -82,6 +92,36 @@ func setVolumeBinder(ctx *Context, binder volumebinding.SchedulerVolumeBinder) {
 	mockedAPI.SetVolumeBinder(binder)
 }
 
+// setTestConf applies the update to a copy of the scheduler configuration and restores the previous
+// configuration when the test ends
+func setTestConf(t *testing.T, update func(c *conf.SchedulerConf)) {
+	prev := conf.GetSchedulerConf()
+	updated := prev.Clone()
+	update(updated)
+	conf.SetSchedulerConf(updated)
+	t.Cleanup(func() {
+		conf.SetSchedulerConf(prev)
+	})
+}
+
+// setTestRecorder installs a fake event recorder and resets it when the test ends
+func setTestRecorder(t *testing.T) *k8sEvents.FakeRecorder {
+	recorder := k8sEvents.NewFakeRecorder(1024)
+	events.SetRecorder(recorder)
+	t.Cleanup(func() {
+		events.SetRecorder(k8sEvents.NewFakeRecorder(1024))
+	})
+	return recorder
+}
+
+// setTestClock replaces the clock and restores the real clock when the test ends
+func setTestClock(t *testing.T, now func() time.Time) {
+	setTimeNow(now)
+	t.Cleanup(func() {
+		setTimeNow(time.Now)
+	})
+}
+
 func newPodHelper(name, namespace, podUID, nodeName string, appID string, podPhase v1.PodPhase) *v1.Pod {
 	return &v1.Pod{
 		TypeMeta: apis.TypeMeta{
@@ -133,6 +173,48 @@ func TestAddNodes(t *testing.T) {
 	assert.Equal(t, true, ctx.schedulerCache.GetNode("host0001") != nil)
 }
 
+func TestAddNodeMaxPods(t *testing.T) {
+	ctx, apiProvider := initContextAndAPIProviderForTest()
+	dispatcher.Start()
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+
+	var registered *si.Resource
+	apiProvider.MockSchedulerAPIUpdateNodeFn(func(request *si.NodeRequest) error {
+		for _, node := range request.Nodes {
+			if node.Action == si.NodeInfo_CREATE_DRAIN {
+				registered = node.SchedulableResource
+				dispatcher.Dispatch(CachedSchedulerNodeEvent{
+					NodeID: node.NodeID,
+					Event:  NodeAccepted,
+				})
+			}
+		}
+		return nil
+	})
+
+	node := nodeForTest(Host1, "10G", "10")
+	node.Status.Allocatable[v1.ResourcePods] = resource.MustParse("110")
+	ctx.addNode(node)
+	assert.Assert(t, registered != nil, "node was not registered")
+	pods, ok := registered.Resources[string(v1.ResourcePods)]
+	assert.Assert(t, ok, "pods resource not forwarded")
+	assert.Equal(t, int64(110), pods.Value)
+
+	// do not forward the pod cap if disabled
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.RespectNodeMaxPods = false
+	})
+
+	registered = nil
+	node = nodeForTest("host0002", "10G", "10")
+	node.Status.Allocatable[v1.ResourcePods] = resource.MustParse("110")
+	ctx.addNode(node)
+	assert.Assert(t, registered != nil, "node was not registered")
+	_, ok = registered.Resources[string(v1.ResourcePods)]
+	assert.Assert(t, !ok, "pods resource should not be forwarded")
+}
+
 func TestUpdateNodes(t *testing.T) {
 	ctx, apiProvider := initContextAndAPIProviderForTest()
 	dispatcher.Start()
@@ -394,8 +476,9 @@ func TestAddPod(t *testing.T) {
 			APIVersion: "v1",
 		},
 		ObjectMeta: apis.ObjectMeta{
-			Name: "yunikorn-test-00001",
-			UID:  "UID-00001",
+			Name:      "yunikorn-test-00001",
+			Namespace: "default",
+			UID:       "UID-00001",
 			Annotations: map[string]string{
 				constants.AnnotationApplicationID: "yunikorn-test-00001",
 			},
@@ -408,8 +491,9 @@ func TestAddPod(t *testing.T) {
 			APIVersion: "v1",
 		},
 		ObjectMeta: apis.ObjectMeta{
-			Name: "yunikorn-test-00002",
-			UID:  "UID-00002",
+			Name:      "yunikorn-test-00002",
+			Namespace: "default",
+			UID:       "UID-00002",
 			Annotations: map[string]string{
 				constants.AnnotationApplicationID: "yunikorn-test-00002",
 			},
@@ -439,8 +523,9 @@ func TestUpdatePod(t *testing.T) {
 			APIVersion: "v1",
 		},
 		ObjectMeta: apis.ObjectMeta{
-			Name: "yunikorn-test-00001",
-			UID:  "UID-00001",
+			Name:      "yunikorn-test-00001",
+			Namespace: "default",
+			UID:       "UID-00001",
 			Annotations: map[string]string{
 				constants.AnnotationApplicationID: "yunikorn-test-00001",
 				"test.state":                      "new",
@@ -454,8 +539,9 @@ func TestUpdatePod(t *testing.T) {
 			APIVersion: "v1",
 		},
 		ObjectMeta: apis.ObjectMeta{
-			Name: "yunikorn-test-00001",
-			UID:  "UID-00001",
+			Name:      "yunikorn-test-00001",
+			Namespace: "default",
+			UID:       "UID-00001",
 			Annotations: map[string]string{
 				constants.AnnotationApplicationID: "yunikorn-test-00001",
 				"test.state":                      "updated",
@@ -469,8 +555,9 @@ func TestUpdatePod(t *testing.T) {
 			APIVersion: "v1",
 		},
 		ObjectMeta: apis.ObjectMeta{
-			Name: "yunikorn-test-00001",
-			UID:  "UID-00001",
+			Name:      "yunikorn-test-00001",
+			Namespace: "default",
+			UID:       "UID-00001",
 			Annotations: map[string]string{
 				constants.AnnotationApplicationID: "yunikorn-test-00001",
 			},
@@ -512,8 +599,9 @@ func TestDeletePod(t *testing.T) {
 			APIVersion: "v1",
 		},
 		ObjectMeta: apis.ObjectMeta{
-			Name: "yunikorn-test-00001",
-			UID:  "UID-00001",
+			Name:      "yunikorn-test-00001",
+			Namespace: "default",
+			UID:       "UID-00001",
 			Annotations: map[string]string{
 				constants.AnnotationApplicationID: "yunikorn-test-00001",
 			},
@@ -526,8 +614,9 @@ func TestDeletePod(t *testing.T) {
 			APIVersion: "v1",
 		},
 		ObjectMeta: apis.ObjectMeta{
-			Name: "yunikorn-test-00002",
-			UID:  "UID-00002",
+			Name:      "yunikorn-test-00002",
+			Namespace: "default",
+			UID:       "UID-00002",
 			Annotations: map[string]string{
 				constants.AnnotationApplicationID: "yunikorn-test-00002",
 			},
@@ -704,6 +793,53 @@ func TestAddUpdatePodForeign(t *testing.T) {
 	assert.Assert(t, !ok, "failed pod found in cache")
 }
 
+func TestAddMirrorPod(t *testing.T) {
+	context, apiProvider := initContextAndAPIProviderForTest()
+	dispatcher.Start()
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+
+	apiProvider.MockSchedulerAPIUpdateNodeFn(func(request *si.NodeRequest) error {
+		for _, node := range request.Nodes {
+			if node.Action == si.NodeInfo_CREATE_DRAIN {
+				dispatcher.Dispatch(CachedSchedulerNodeEvent{
+					NodeID: node.NodeID,
+					Event:  NodeAccepted,
+				})
+			}
+		}
+		return nil
+	})
+
+	host1 := nodeForTest(Host1, "10G", "10")
+	context.updateNode(nil, host1)
+
+	// mirror pod targeting yunikorn with an application ID
+	pod := foreignPod("mirror-pod", "1G", "500m")
+	pod.Namespace = "kube-system"
+	pod.Labels = map[string]string{constants.LabelApplicationID: appID1}
+	pod.Annotations = map[string]string{v1.MirrorPodAnnotationKey: "mirror"}
+	pod.Spec.SchedulerName = constants.SchedulerName
+	pod.Spec.NodeName = Host1
+	pod.Status.Phase = v1.PodRunning
+
+	context.AddPod(pod)
+	assert.Assert(t, context.GetApplication(appID1) == nil, "application created for mirror pod")
+	_, ok := context.schedulerCache.GetPod(string(pod.UID))
+	assert.Assert(t, ok, "mirror pod not found in cache")
+	_, occupied, ok := context.schedulerCache.SnapshotResources(Host1)
+	assert.Assert(t, ok, "unable to snapshot node resources")
+	assert.Equal(t, occupied.Resources[siCommon.Memory].Value, int64(1000*1000*1000), "wrong occupied memory")
+	assert.Equal(t, occupied.Resources[siCommon.CPU].Value, int64(500), "wrong occupied cpu")
+
+	// removal releases the occupied resources
+	context.DeletePod(pod)
+	_, occupied, ok = context.schedulerCache.SnapshotResources(Host1)
+	assert.Assert(t, ok, "unable to snapshot node resources")
+	assert.Equal(t, occupied.Resources[siCommon.Memory].Value, int64(0), "wrong occupied memory")
+	assert.Equal(t, occupied.Resources[siCommon.CPU].Value, int64(0), "wrong occupied cpu")
+}
+
 func TestDeletePodForeign(t *testing.T) {
 	context, apiProvider := initContextAndAPIProviderForTest()
 	dispatcher.Start()
@@ -996,6 +1132,60 @@ func TestRecoverTask(t *testing.T) {
 	}
 }
 
+func TestRecoverPresetNodeTask(t *testing.T) {
+	context, apiProvider := initContextAndAPIProviderForTest()
+	dispatcher.Start()
+	dispatcher.RegisterEventHandler("TestAppHandler", dispatcher.EventTypeApp, context.ApplicationEventHandler())
+	dispatcher.RegisterEventHandler("TestTaskHandler", dispatcher.EventTypeTask, context.TaskEventHandler())
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+
+	var allocatedNode atomic.Value
+	apiProvider.MockSchedulerAPIUpdateAllocationFn(func(request *si.AllocationRequest) error {
+		for _, alloc := range request.Allocations {
+			allocatedNode.Store(alloc.NodeID)
+			dispatcher.Dispatch(NewAllocateTaskEvent(alloc.ApplicationID, alloc.AllocationKey, alloc.AllocationKey, alloc.NodeID))
+		}
+		return nil
+	})
+	var bindCalled atomic.Bool
+	apiProvider.MockBindFn(func(pod *v1.Pod, hostID string) error {
+		bindCalled.Store(true)
+		return nil
+	})
+
+	app := context.AddApplication(&AddApplicationRequest{
+		Metadata: ApplicationMetadata{
+			ApplicationID: appID1,
+			QueueName:     "root.a",
+			User:          "test-user",
+		},
+	})
+
+	// pending pod created with the node name already set
+	pod := newPodHelper("pod1", "default", "task0001", Host1, appID1, v1.PodPending)
+	task := context.AddTask(&AddTaskRequest{
+		Metadata: TaskMetadata{
+			ApplicationID: appID1,
+			TaskID:        "task0001",
+			Pod:           pod,
+		},
+	})
+	assert.Assert(t, task != nil)
+	assert.Equal(t, task.GetTaskState(), TaskStates().New)
+
+	app.SetState("Running")
+	app.Schedule()
+
+	err := utils.WaitForCondition(func() bool {
+		return task.GetTaskState() == TaskStates().Bound
+	}, 10*time.Millisecond, 3*time.Second)
+	assert.NilError(t, err, "task was not recovered as bound")
+	assert.Equal(t, allocatedNode.Load(), Host1, "allocation not sent for preset node")
+	assert.Equal(t, task.getNodeName(), Host1)
+	assert.Assert(t, !bindCalled.Load(), "pod with preset node should not be bound again")
+}
+
 func TestTaskReleaseAfterRecovery(t *testing.T) {
 	context, apiProvider := initContextAndAPIProviderForTest()
 	dispatcher.RegisterEventHandler("TestAppHandler", dispatcher.EventTypeApp, context.ApplicationEventHandler())
@@ -1629,6 +1819,111 @@ func TestAddApplicationsWithTags(t *testing.T) {
 	}
 }
 
+func TestAddApplicationNamespacePreemption(t *testing.T) {
+	context := initContextForTest()
+
+	lister, ok := context.apiProvider.GetAPIs().NamespaceInformer.Lister().(*test.MockNamespaceLister)
+	if !ok {
+		t.Fatalf("could not mock NamespaceLister")
+	}
+	lister.Add(&v1.Namespace{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "no-preempt",
+			Annotations: map[string]string{
+				constants.AnnotationAllowPreemption: constants.False,
+			},
+		},
+	})
+	lister.Add(&v1.Namespace{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "invalid",
+			Annotations: map[string]string{
+				constants.AnnotationAllowPreemption: "maybe",
+			},
+		},
+	})
+
+	app := context.AddApplication(&AddApplicationRequest{
+		Metadata: ApplicationMetadata{
+			ApplicationID: appID1,
+			QueueName:     "root.a",
+			User:          "test-user",
+			Tags: map[string]string{
+				constants.AppTagNamespace: "no-preempt",
+			},
+		},
+	})
+	assert.Equal(t, app.GetTags()[constants.AppTagNamespaceAllowPreemption], constants.False)
+
+	// pods inherit the namespace default
+	task := NewTask("task0001", app, context, &v1.Pod{})
+	assert.Assert(t, !task.isPreemptSelfAllowed(), "namespace default not inherited")
+
+	// pod annotation overrides the namespace default
+	task = NewTask("task0002", app, context, &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Annotations: map[string]string{
+				constants.AnnotationAllowPreemption: constants.True,
+			},
+		},
+	})
+	assert.Assert(t, task.isPreemptSelfAllowed(), "pod annotation did not override namespace default")
+
+	// invalid values are ignored
+	app = context.AddApplication(&AddApplicationRequest{
+		Metadata: ApplicationMetadata{
+			ApplicationID: appID2,
+			QueueName:     "root.a",
+			User:          "test-user",
+			Tags: map[string]string{
+				constants.AppTagNamespace: "invalid",
+			},
+		},
+	})
+	_, ok = app.GetTags()[constants.AppTagNamespaceAllowPreemption]
+	assert.Assert(t, !ok, "invalid namespace annotation should be ignored")
+	task = NewTask("task0003", app, context, &v1.Pod{})
+	assert.Assert(t, task.isPreemptSelfAllowed(), "default should allow preemption")
+
+	// priority class opt-out overrides the namespace default
+	lister.Add(&v1.Namespace{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "preempt",
+			Annotations: map[string]string{
+				constants.AnnotationAllowPreemption: constants.True,
+			},
+		},
+	})
+	context.addPriorityClass(&schedulingv1.PriorityClass{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "no-preempt-pc",
+			Annotations: map[string]string{
+				constants.AnnotationAllowPreemption: constants.False,
+			},
+		},
+		Value: 100,
+	})
+	app = context.AddApplication(&AddApplicationRequest{
+		Metadata: ApplicationMetadata{
+			ApplicationID: appID3,
+			QueueName:     "root.a",
+			User:          "test-user",
+			Tags: map[string]string{
+				constants.AppTagNamespace: "preempt",
+			},
+		},
+	})
+	assert.Equal(t, app.GetTags()[constants.AppTagNamespaceAllowPreemption], constants.True)
+	task = NewTask("task0004", app, context, &v1.Pod{})
+	assert.Assert(t, task.isPreemptSelfAllowed(), "namespace default not inherited")
+	task = NewTask("task0005", app, context, &v1.Pod{
+		Spec: v1.PodSpec{
+			PriorityClassName: "no-preempt-pc",
+		},
+	})
+	assert.Assert(t, !task.isPreemptSelfAllowed(), "priority class opt-out overridden by namespace default")
+}
+
 func TestPendingPodAllocations(t *testing.T) {
 	utils.SetPluginMode(true)
 	defer utils.SetPluginMode(false)
@@ -2224,66 +2519,369 @@ func TestAssumePod_PodNotFound(t *testing.T) {
 	assert.Equal(t, podInCache.Spec.NodeName, "", "NodeName in pod spec was set unexpectedly")
 }
 
-func initAssumePodTest(binder *test.VolumeBinderMock) *Context {
-	context, apiProvider := initContextAndAPIProviderForTest()
-	if binder != nil {
-		setVolumeBinder(context, binder)
+func TestGetApplicationTasksByNode(t *testing.T) {
+	context := initContextForTest()
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+
+	task1 := NewTask("task0001", app, context, &v1.Pod{})
+	task2 := NewTask("task0002", app, context, &v1.Pod{})
+	task3 := NewTask("task0003", app, context, &v1.Pod{})
+	task4 := NewTask("task0004", app, context, &v1.Pod{})
+	app.addTask(task1)
+	app.addTask(task2)
+	app.addTask(task3)
+	app.addTask(task4)
+
+	task1.MarkPreviouslyAllocated("alloc-0001", "node-1")
+	task2.MarkPreviouslyAllocated("alloc-0002", "node-1")
+	task3.MarkPreviouslyAllocated("alloc-0003", "node-2")
+	// task4 is not bound and must not show up
+
+	tasksByNode := context.GetApplicationTasksByNode(appID1)
+	assert.Equal(t, len(tasksByNode), 2)
+	assert.Equal(t, len(tasksByNode["node-1"]), 2)
+	assert.Equal(t, len(tasksByNode["node-2"]), 1)
+	assert.Equal(t, tasksByNode["node-2"][0], task3)
+	taskIDs := map[string]bool{
+		tasksByNode["node-1"][0].GetTaskID(): true,
+		tasksByNode["node-1"][1].GetTaskID(): true,
+	}
+	assert.Assert(t, taskIDs["task0001"])
+	assert.Assert(t, taskIDs["task0002"])
+
+	assert.Assert(t, context.GetApplicationTasksByNode("non-existing-app") == nil)
+}
+
+func TestGetApplicationLastActivity(t *testing.T) {
+	context := initContextForTest()
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+	task := NewTask("task0001", app, context, &v1.Pod{})
+	app.addTask(task)
+
+	// no task state changes yet
+	assert.Assert(t, context.GetApplicationLastActivity(appID1).IsZero())
+	assert.Assert(t, context.GetApplicationLastActivity("non-existing-app").IsZero())
+
+	first := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	now := first
+	setTestClock(t, func() time.Time { return now })
+	err := task.handle(NewSimpleTaskEvent(appID1, "task0001", InitTask))
+	assert.NilError(t, err)
+	assert.Equal(t, task.GetTaskState(), TaskStates().Pending)
+	assert.Assert(t, context.GetApplicationLastActivity(appID1).Equal(first), "last activity not updated on transition")
+
+	now = first.Add(time.Minute)
+	err = task.handle(NewSimpleTaskEvent(appID1, "task0001", CompleteTask))
+	assert.NilError(t, err)
+	assert.Equal(t, task.GetTaskState(), TaskStates().Completed)
+	assert.Assert(t, context.GetApplicationLastActivity(appID1).Equal(now), "last activity not updated on second transition")
+}
+
+func TestGetApplicationTaskTimeline(t *testing.T) {
+	context := initContextForTest()
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+	task1 := NewTask("task0001", app, context, &v1.Pod{})
+	task2 := NewTask("task0002", app, context, &v1.Pod{})
+	app.addTask(task1)
+	app.addTask(task2)
+
+	assert.Equal(t, len(context.GetApplicationTaskTimeline(appID1)), 0)
+	assert.Assert(t, context.GetApplicationTaskTimeline("non-existing-app") == nil)
+
+	// interleave the transitions of both tasks
+	assert.NilError(t, task1.handle(NewSimpleTaskEvent(appID1, "task0001", InitTask)))
+	time.Sleep(time.Millisecond)
+	assert.NilError(t, task2.handle(NewSimpleTaskEvent(appID1, "task0002", InitTask)))
+	time.Sleep(time.Millisecond)
+	assert.NilError(t, task1.handle(NewSimpleTaskEvent(appID1, "task0001", CompleteTask)))
+	time.Sleep(time.Millisecond)
+	assert.NilError(t, task2.handle(NewSimpleTaskEvent(appID1, "task0002", CompleteTask)))
+
+	timeline := context.GetApplicationTaskTimeline(appID1)
+	assert.Equal(t, len(timeline), 4)
+	expected := []TaskTransition{
+		{TaskID: "task0001", From: TaskStates().New, To: TaskStates().Pending, Event: InitTask.String()},
+		{TaskID: "task0002", From: TaskStates().New, To: TaskStates().Pending, Event: InitTask.String()},
+		{TaskID: "task0001", From: TaskStates().Pending, To: TaskStates().Completed, Event: CompleteTask.String()},
+		{TaskID: "task0002", From: TaskStates().Pending, To: TaskStates().Completed, Event: CompleteTask.String()},
+	}
+	for i, transition := range timeline {
+		assert.Equal(t, transition.TaskID, expected[i].TaskID)
+		assert.Equal(t, transition.From, expected[i].From)
+		assert.Equal(t, transition.To, expected[i].To)
+		assert.Equal(t, transition.Event, expected[i].Event)
+		if i > 0 {
+			assert.Assert(t, transition.Time.After(timeline[i-1].Time), "timeline not ordered")
+		}
 	}
+}
+
+func TestGetNodeAllocationSummary(t *testing.T) {
+	context, apiProvider := initContextAndAPIProviderForTest()
 	dispatcher.Start()
-	dispatcher.RegisterEventHandler("TestAppHandler", dispatcher.EventTypeApp, context.ApplicationEventHandler())
-	dispatcher.RegisterEventHandler("TestTaskHandler", dispatcher.EventTypeTask, context.TaskEventHandler())
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+
 	apiProvider.MockSchedulerAPIUpdateNodeFn(func(request *si.NodeRequest) error {
 		for _, node := range request.Nodes {
-			dispatcher.Dispatch(CachedSchedulerNodeEvent{
-				NodeID: node.NodeID,
-				Event:  NodeAccepted,
-			})
+			if node.Action == si.NodeInfo_CREATE_DRAIN {
+				dispatcher.Dispatch(CachedSchedulerNodeEvent{
+					NodeID: node.NodeID,
+					Event:  NodeAccepted,
+				})
+			}
 		}
 		return nil
 	})
-	context.AddApplication(&AddApplicationRequest{
-		Metadata: ApplicationMetadata{
-			ApplicationID: appID,
-			QueueName:     queue,
-			User:          "test-user",
-			Tags:          nil,
-		},
-	})
-	pod := newPodHelper(pod1Name, namespace, pod1UID, "", appID, v1.PodRunning)
-	context.AddPod(pod)
-	node := v1.Node{
-		ObjectMeta: apis.ObjectMeta{
-			Name:      fakeNodeName,
-			Namespace: "default",
-			UID:       "uid_0001",
-		},
-	}
-	context.addNode(&node)
 
-	return context
+	_, ok := context.GetNodeAllocationSummary(Host1)
+	assert.Assert(t, !ok, "summary returned for unknown node")
+
+	context.updateNode(nil, nodeForTest(Host1, "10G", "10"))
+
+	// foreign pod occupies resources
+	foreign := foreignPod("foreign-pod", "1G", "500m")
+	foreign.Spec.NodeName = Host1
+	foreign.Status.Phase = v1.PodRunning
+	context.AddPod(foreign)
+
+	// bound task
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+	task1 := NewTask("task0001", app, context, foreignPod("task0001", "2G", "1"))
+	app.addTask(task1)
+	task1.MarkPreviouslyAllocated("alloc-0001", Host1)
+	// task bound on another node is not counted
+	task2 := NewTask("task0002", app, context, foreignPod("task0002", "2G", "1"))
+	app.addTask(task2)
+	task2.MarkPreviouslyAllocated("alloc-0002", "host0002")
+
+	// pending allocation
+	pending := foreignPod("pending-pod", "3G", "2")
+	context.schedulerCache.UpdatePod(pending)
+	context.schedulerCache.AddPendingPodAllocation(string(pending.UID), Host1)
+
+	summary, ok := context.GetNodeAllocationSummary(Host1)
+	assert.Assert(t, ok, "summary not found")
+	assert.Equal(t, summary.Capacity.Resources[siCommon.Memory].Value, int64(10*1000*1000*1000))
+	assert.Equal(t, summary.Capacity.Resources[siCommon.CPU].Value, int64(10000))
+	assert.Equal(t, summary.Occupied.Resources[siCommon.Memory].Value, int64(1000*1000*1000))
+	assert.Equal(t, summary.Occupied.Resources[siCommon.CPU].Value, int64(500))
+	assert.Equal(t, summary.Allocated.Resources[siCommon.Memory].Value, int64(2*1000*1000*1000))
+	assert.Equal(t, summary.Allocated.Resources[siCommon.CPU].Value, int64(1000))
+	assert.Equal(t, summary.PendingAllocation.Resources[siCommon.Memory].Value, int64(3*1000*1000*1000))
+	assert.Equal(t, summary.PendingAllocation.Resources[siCommon.CPU].Value, int64(2000))
 }
 
-func waitForNodeAcceptedEvent(recorder *k8sEvents.FakeRecorder) error {
-	// fetch the "node accepted" event
-	err := utils.WaitForCondition(func() bool {
-		for {
-			select {
-			case event := <-recorder.Events:
-				log.Log(log.Test).Info(event)
-				if strings.Contains(event, "accepted by the scheduler") {
-					return true
-				}
-			default:
-				return false
-			}
-		}
-	}, 10*time.Millisecond, time.Second)
-	return err
+func TestGetTasksWaitingOnVolumes(t *testing.T) {
+	context := initContextForTest()
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+
+	pod1 := newPodHelper("pod1", "default", "task0001", Host1, appID1, v1.PodPending)
+	pod2 := newPodHelper("pod2", "default", "task0002", Host1, appID1, v1.PodPending)
+	pod3 := newPodHelper("pod3", "default", "task0003", Host1, appID1, v1.PodPending)
+	task1 := NewTask("task0001", app, context, pod1)
+	task2 := NewTask("task0002", app, context, pod2)
+	task3 := NewTask("task0003", app, context, pod3)
+	app.addTask(task1)
+	app.addTask(task2)
+	app.addTask(task3)
+
+	assert.Equal(t, len(context.GetTasksWaitingOnVolumes()), 0)
+
+	// pod1 still has unbound volumes, pod2 has all volumes bound, pod3 is not assumed
+	context.schedulerCache.AssumePod(pod1, false)
+	context.schedulerCache.AssumePod(pod2, true)
+
+	tasks := context.GetTasksWaitingOnVolumes()
+	assert.Equal(t, len(tasks), 1)
+	assert.Equal(t, tasks[0], task1)
 }
 
-func nodeForTest(nodeID, memory, cpu string) *v1.Node {
-	resourceList := make(map[v1.ResourceName]resource.Quantity)
-	resourceList[v1.ResourceName("memory")] = resource.MustParse(memory)
+// fakeRateLimiterClock is a manually advanced clock for the allocation request rate limiter
+type fakeRateLimiterClock struct {
+	now time.Time
+}
+
+func (c *fakeRateLimiterClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeRateLimiterClock) Since(t time.Time) time.Duration {
+	return c.now.Sub(t)
+}
+
+func (c *fakeRateLimiterClock) Sleep(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestScheduleTasksThrottled(t *testing.T) {
+	conf.GetSchedulerConf().SetTestMode(true)
+	apis := client.NewMockedAPIProvider(false)
+	apis.GetAPIs().GetConf().AllocationRequestQPS = 10
+	apis.GetAPIs().GetConf().AllocationRequestBurst = 1
+	context := NewContext(apis)
+	assert.Assert(t, context.allocationLimiter != nil, "rate limiter not created")
+	clock := &fakeRateLimiterClock{now: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)}
+	context.allocationLimiter = flowcontrol.NewTokenBucketRateLimiterWithClock(10, 1, clock)
+
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, apis.GetAPIs().SchedulerAPI)
+	context.addApplicationToContext(app)
+	app.sm.SetState(ApplicationStates().Running)
+	tasks := make([]*Task, 0)
+	for i := 0; i < 3; i++ {
+		taskID := fmt.Sprintf("task%04d", i)
+		task := NewTask(taskID, app, context, newPodHelper(taskID, "default", taskID, "", appID1, v1.PodPending))
+		app.addTask(task)
+		tasks = append(tasks, task)
+	}
+	countPending := func() int {
+		pending := 0
+		for _, task := range tasks {
+			if task.GetTaskState() == TaskStates().Pending {
+				pending++
+			}
+		}
+		return pending
+	}
+
+	// the first task uses the burst, the others stay new without blocking the scheduling cycle
+	app.Schedule()
+	assert.Equal(t, countPending(), 1)
+	assert.Equal(t, len(app.GetNewTasks()), 2)
+	app.Schedule()
+	assert.Equal(t, countPending(), 1, "task submitted without a token")
+
+	// a token is available after 100ms at 10 QPS
+	clock.Sleep(100 * time.Millisecond)
+	app.Schedule()
+	assert.Equal(t, countPending(), 2)
+	clock.Sleep(100 * time.Millisecond)
+	app.Schedule()
+	assert.Equal(t, countPending(), 3)
+
+	// no limiter by default
+	context, _ = initContextAndAPIProviderForTest()
+	assert.Assert(t, context.allocationLimiter == nil, "rate limiter should not be created")
+	assert.Assert(t, context.tryAcceptAllocationRequest())
+}
+
+func TestAddTaskMaxPodResource(t *testing.T) {
+	recorder := setTestRecorder(t)
+	context := initContextForTest()
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.MaxPodResource = map[string]string{"memory": "2G", "cpu": "1"}
+	})
+
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+
+	// pod within the limits is added as normal
+	task := context.AddTask(&AddTaskRequest{
+		Metadata: TaskMetadata{
+			ApplicationID: appID1,
+			TaskID:        "task0001",
+			Pod:           foreignPod("task0001", "1G", "500m"),
+		},
+	})
+	assert.Assert(t, task != nil)
+	assert.Equal(t, task.GetTaskState(), TaskStates().New)
+
+	// pod over the limit is created as failed
+	task = context.AddTask(&AddTaskRequest{
+		Metadata: TaskMetadata{
+			ApplicationID: appID1,
+			TaskID:        "task0002",
+			Pod:           foreignPod("task0002", "4G", "500m"),
+		},
+	})
+	assert.Assert(t, task != nil)
+	assert.Equal(t, task.GetTaskState(), TaskStates().Failed)
+	assert.Equal(t, len(app.GetNewTasks()), 1)
+	assert.Equal(t, len(recorder.Events), 1)
+	assert.Equal(t, <-recorder.Events,
+		"Warning PodResourceExceeded pod request exceeds the maximum allowed for resource(s) memory")
+
+	// no limit configured
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.MaxPodResource = nil
+	})
+	task = context.AddTask(&AddTaskRequest{
+		Metadata: TaskMetadata{
+			ApplicationID: appID1,
+			TaskID:        "task0003",
+			Pod:           foreignPod("task0003", "4G", "500m"),
+		},
+	})
+	assert.Assert(t, task != nil)
+	assert.Equal(t, task.GetTaskState(), TaskStates().New)
+	assert.Equal(t, len(recorder.Events), 0)
+}
+
+func initAssumePodTest(binder *test.VolumeBinderMock) *Context {
+	context, apiProvider := initContextAndAPIProviderForTest()
+	if binder != nil {
+		setVolumeBinder(context, binder)
+	}
+	dispatcher.Start()
+	dispatcher.RegisterEventHandler("TestAppHandler", dispatcher.EventTypeApp, context.ApplicationEventHandler())
+	dispatcher.RegisterEventHandler("TestTaskHandler", dispatcher.EventTypeTask, context.TaskEventHandler())
+	apiProvider.MockSchedulerAPIUpdateNodeFn(func(request *si.NodeRequest) error {
+		for _, node := range request.Nodes {
+			dispatcher.Dispatch(CachedSchedulerNodeEvent{
+				NodeID: node.NodeID,
+				Event:  NodeAccepted,
+			})
+		}
+		return nil
+	})
+	context.AddApplication(&AddApplicationRequest{
+		Metadata: ApplicationMetadata{
+			ApplicationID: appID,
+			QueueName:     queue,
+			User:          "test-user",
+			Tags:          nil,
+		},
+	})
+	pod := newPodHelper(pod1Name, namespace, pod1UID, "", appID, v1.PodRunning)
+	context.AddPod(pod)
+	node := v1.Node{
+		ObjectMeta: apis.ObjectMeta{
+			Name:      fakeNodeName,
+			Namespace: "default",
+			UID:       "uid_0001",
+		},
+	}
+	context.addNode(&node)
+
+	return context
+}
+
+func waitForNodeAcceptedEvent(recorder *k8sEvents.FakeRecorder) error {
+	// fetch the "node accepted" event
+	err := utils.WaitForCondition(func() bool {
+		for {
+			select {
+			case event := <-recorder.Events:
+				log.Log(log.Test).Info(event)
+				if strings.Contains(event, "accepted by the scheduler") {
+					return true
+				}
+			default:
+				return false
+			}
+		}
+	}, 10*time.Millisecond, time.Second)
+	return err
+}
+
+func nodeForTest(nodeID, memory, cpu string) *v1.Node {
+	resourceList := make(map[v1.ResourceName]resource.Quantity)
+	resourceList[v1.ResourceName("memory")] = resource.MustParse(memory)
 	resourceList[v1.ResourceName("cpu")] = resource.MustParse(cpu)
 	return &v1.Node{
 		TypeMeta: apis.TypeMeta{
@@ -2320,11 +2918,2711 @@ func foreignPod(podName, memory, cpu string) *v1.Pod {
 			APIVersion: "v1",
 		},
 		ObjectMeta: apis.ObjectMeta{
-			Name: podName,
-			UID:  types.UID(podName),
+			Name:      podName,
+			Namespace: "default",
+			UID:       types.UID(podName),
 		},
 		Spec: v1.PodSpec{
 			Containers: containers,
 		},
 	}
 }
+
+func TestGetSchedulerConfigChecksum(t *testing.T) {
+	// reloading updates the global configuration, restore it afterwards
+	prev := conf.GetSchedulerConf()
+	defer conf.SetSchedulerConf(prev)
+
+	context, apiProvider := initContextAndAPIProviderForTest()
+	apiProvider.GetAPIs().GetConf().EnableConfigHotRefresh = true
+	initial := context.GetSchedulerConfigChecksum()
+	assert.Assert(t, initial != "", "checksum not set on creation")
+
+	configMap := &v1.ConfigMap{
+		ObjectMeta: apis.ObjectMeta{Name: constants.ConfigMapName},
+		Data:       map[string]string{"queues.yaml": "partitions:\n- name: default\n"},
+	}
+	context.triggerReloadConfig(1, configMap)
+	changed := context.GetSchedulerConfigChecksum()
+	assert.Assert(t, changed != initial, "checksum not updated on config change")
+
+	// reloading the same content keeps the checksum stable
+	context.triggerReloadConfig(1, configMap.DeepCopy())
+	assert.Equal(t, context.GetSchedulerConfigChecksum(), changed)
+
+	configMap.Data["queues.yaml"] = "partitions:\n- name: other\n"
+	context.triggerReloadConfig(1, configMap)
+	assert.Assert(t, context.GetSchedulerConfigChecksum() != changed, "checksum not updated on value change")
+
+	// removing the configmap returns to the initial configuration
+	context.triggerReloadConfig(1, nil)
+	assert.Equal(t, context.GetSchedulerConfigChecksum(), initial)
+
+	// a configuration the core did not accept does not change the checksum
+	apiProvider.MockSchedulerAPIUpdateConfigurationFn(func(request *si.UpdateConfigurationRequest) error {
+		return fmt.Errorf("invalid configuration")
+	})
+	context.triggerReloadConfig(1, configMap)
+	assert.Equal(t, context.GetSchedulerConfigChecksum(), initial)
+}
+
+func TestGetPendingTasksSortedByPriority(t *testing.T) {
+	context := initContextForTest()
+	app1 := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	app2 := NewApplication(appID2, "root.b", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app1)
+	context.addApplicationToContext(app2)
+
+	now := time.Now()
+	newPod := func(name string, priority *int32, created time.Time) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: apis.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				CreationTimestamp: apis.NewTime(created),
+			},
+			Spec: v1.PodSpec{Priority: priority},
+		}
+	}
+	high := int32(1000)
+	low := int32(-10)
+	tasks := []*Task{
+		NewTask("low", app1, context, newPod("low", &low, now)),
+		NewTask("default", app2, context, newPod("default", nil, now)),
+		NewTask("high-new", app1, context, newPod("high-new", &high, now.Add(time.Second))),
+		NewTask("high-old", app2, context, newPod("high-old", &high, now.Add(-time.Second))),
+		NewTask("running", app1, context, newPod("running", &high, now)),
+	}
+	for _, task := range tasks {
+		task.application.addTask(task)
+		switch task.GetTaskID() {
+		case "running":
+			task.sm.SetState(TaskStates().Bound)
+		case "high-old":
+			// submitted to the core but not allocated yet
+			task.sm.SetState(TaskStates().Scheduling)
+		default:
+			task.sm.SetState(TaskStates().Pending)
+		}
+	}
+
+	sorted := context.GetPendingTasksSortedByPriority()
+	ids := make([]string, 0, len(sorted))
+	for _, task := range sorted {
+		ids = append(ids, task.GetTaskID())
+	}
+	assert.DeepEqual(t, ids, []string{"high-old", "high-new", "default", "low"})
+}
+
+func TestDeleteNamespace(t *testing.T) {
+	context, apiProvider := initContextAndAPIProviderForTest()
+	removed := make([]string, 0)
+	lockHeld := false
+	apiProvider.MockSchedulerAPIUpdateApplicationFn(func(request *si.ApplicationRequest) error {
+		// the context lock must not be held while calling the core
+		unlocked := make(chan struct{})
+		go func() {
+			context.lock.RLock()
+			context.lock.RUnlock()
+			close(unlocked)
+		}()
+		select {
+		case <-unlocked:
+		case <-time.After(time.Second):
+			lockHeld = true
+		}
+		for _, app := range request.Remove {
+			removed = append(removed, app.ApplicationID)
+		}
+		return nil
+	})
+	app1 := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{constants.AppTagNamespace: "ns1"}, apiProvider.GetAPIs().SchedulerAPI)
+	app1.sm.SetState(ApplicationStates().Running)
+	app2 := NewApplication(appID2, "root.a", "testuser", testGroups, map[string]string{constants.AppTagNamespace: "ns2"}, apiProvider.GetAPIs().SchedulerAPI)
+	context.addApplicationToContext(app1)
+	context.addApplicationToContext(app2)
+	namespace := &v1.Namespace{ObjectMeta: apis.ObjectMeta{Name: "ns1"}}
+
+	// cleanup is disabled by default
+	context.deleteNamespace(namespace)
+	assert.Assert(t, context.GetApplication(appID1) != nil, "application removed while cleanup disabled")
+
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.CleanupOnNamespaceDelete = true
+	})
+
+	context.deleteNamespace(cache.DeletedFinalStateUnknown{Key: "ns1", Obj: namespace})
+	assert.Assert(t, context.GetApplication(appID1) == nil, "application of deleted namespace not removed")
+	assert.Equal(t, app1.GetApplicationState(), ApplicationStates().Failing)
+	assert.DeepEqual(t, removed, []string{appID1})
+	assert.Assert(t, !lockHeld, "context lock held while calling the core")
+	assert.Assert(t, context.GetApplication(appID2) != nil, "application of other namespace removed")
+}
+
+func TestScoreNodesForTask(t *testing.T) {
+	context := initContextForTest()
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+	task := NewTask("task01", app, context, &v1.Pod{})
+	app.addTask(task)
+	context.schedulerCache.UpdateNode(nodeForTest(Host1, "10G", "10"))
+	context.schedulerCache.UpdateNode(nodeForTest(Host2, "10G", "10"))
+
+	assert.Assert(t, context.ScoreNodesForTask(appID1, "unknown") == nil)
+
+	// default scorer has no preference
+	assert.DeepEqual(t, context.ScoreNodesForTask(appID1, "task01"), map[string]int64{Host1: 0, Host2: 0})
+
+	context.SetNodeScorer(func(task *Task, nodeID string) int64 {
+		if task.GetTaskID() == "task01" && nodeID == Host2 {
+			return 100
+		}
+		return 10
+	})
+	assert.DeepEqual(t, context.ScoreNodesForTask(appID1, "task01"), map[string]int64{Host1: 10, Host2: 100})
+
+	context.SetNodeScorer(nil)
+	assert.DeepEqual(t, context.ScoreNodesForTask(appID1, "task01"), map[string]int64{Host1: 0, Host2: 0})
+}
+
+func TestAddApplicationTeamLabel(t *testing.T) {
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.TeamLabelKey = "example.com/team"
+	})
+
+	context := initContextForTest()
+	pod := newPodHelper(pod1Name, "default", pod1UID, "", appID1, v1.PodPending)
+	pod.Labels["example.com/team"] = "data-platform"
+	metadata, ok := getAppMetadata(pod)
+	assert.Assert(t, ok)
+	app := context.AddApplication(&AddApplicationRequest{Metadata: metadata})
+	assert.Equal(t, app.GetTeam(), "data-platform")
+	assert.Equal(t, app.GetTags()[constants.AppTagTeam], "data-platform")
+
+	// the team is only taken from the pod that submits the application, later tasks do not change it
+	app2 := context.AddApplication(&AddApplicationRequest{
+		Metadata: ApplicationMetadata{
+			ApplicationID: appID2,
+			QueueName:     "root.a",
+			User:          "test-user",
+			Tags:          map[string]string{},
+		},
+	})
+	assert.Equal(t, app2.GetTeam(), "")
+	pod2 := newPodHelper("my-pod-2", "default", "task00002", "", appID2, v1.PodPending)
+	pod2.Labels["example.com/team"] = "ml"
+	context.AddTask(&AddTaskRequest{
+		Metadata: TaskMetadata{
+			ApplicationID: appID2,
+			TaskID:        "task00002",
+			Pod:           pod2,
+		},
+	})
+	assert.Equal(t, app2.GetTeam(), "")
+	_, ok = app2.GetTags()[constants.AppTagTeam]
+	assert.Assert(t, !ok)
+}
+
+func TestDrainNodes(t *testing.T) {
+	context, apiProvider := initContextAndAPIProviderForTest()
+	requests := make([]*si.NodeRequest, 0)
+	apiProvider.MockSchedulerAPIUpdateNodeFn(func(request *si.NodeRequest) error {
+		requests = append(requests, request)
+		return nil
+	})
+	nodeIDs := []string{"host0001", "host0002", "host0003"}
+	for _, nodeID := range nodeIDs {
+		context.schedulerCache.UpdateNode(nodeForTest(nodeID, "10G", "10"))
+	}
+
+	errs := context.DrainNodes(nodeIDs)
+	assert.Equal(t, len(errs), 0)
+	assert.Equal(t, len(requests), 1, "nodes were not drained in a single request")
+	assert.Equal(t, len(requests[0].Nodes), 3)
+	for i, node := range requests[0].Nodes {
+		assert.Equal(t, node.NodeID, nodeIDs[i])
+		assert.Equal(t, node.Action, si.NodeInfo_DRAIN_NODE)
+	}
+
+	// unknown nodes are reported and not sent to the core
+	requests = requests[:0]
+	errs = context.DrainNodes([]string{"host0001", "unknown"})
+	assert.Equal(t, len(errs), 1)
+	assert.ErrorContains(t, errs["unknown"], "not found")
+	assert.Equal(t, len(requests), 1)
+	assert.Equal(t, len(requests[0].Nodes), 1)
+
+	// core failure is reported for each node in the request
+	apiProvider.MockSchedulerAPIUpdateNodeFn(func(request *si.NodeRequest) error {
+		return fmt.Errorf("core unavailable")
+	})
+	errs = context.DrainNodes([]string{"host0002", "host0003"})
+	assert.Equal(t, len(errs), 2)
+	assert.ErrorContains(t, errs["host0002"], "core unavailable")
+	assert.ErrorContains(t, errs["host0003"], "core unavailable")
+}
+
+func TestAddPodWithoutNamespace(t *testing.T) {
+	context := initContextForTest()
+	pod := newPodHelper(pod1Name, "", pod1UID, "", appID1, v1.PodPending)
+	context.AddPod(pod)
+	_, ok := context.schedulerCache.GetPod(pod1UID)
+	assert.Assert(t, !ok, "pod without namespace was cached")
+	assert.Assert(t, context.GetApplication(appID1) == nil, "application created for pod without namespace")
+
+	// foreign pods are skipped as well
+	foreign := newPodHelper("foreign-pod", "", "foreign-uid", fakeNodeName, "", v1.PodRunning)
+	foreign.Spec.SchedulerName = "default-scheduler"
+	context.AddPod(foreign)
+	_, ok = context.schedulerCache.GetPod("foreign-uid")
+	assert.Assert(t, !ok, "foreign pod without namespace was cached")
+
+	dump, err := context.GetStateDump()
+	assert.NilError(t, err)
+	assert.Assert(t, !strings.Contains(dump, "\"/"+pod1Name+"\""), "malformed pod key in state dump")
+	assert.Assert(t, !strings.Contains(dump, "\"/foreign-pod\""), "malformed pod key in state dump")
+}
+
+func TestGetApplicationsFilter(t *testing.T) {
+	context := initContextForTest()
+	app1 := NewApplication(appID1, "root.a", "alice", testGroups, map[string]string{"env": "prod"}, newMockSchedulerAPI())
+	app2 := NewApplication(appID2, "root.a", "bob", testGroups, map[string]string{"env": "dev"}, newMockSchedulerAPI())
+	app3 := NewApplication(appID3, "root.b", "alice", testGroups, map[string]string{"env": "prod", "team": "ml"}, newMockSchedulerAPI())
+	app3.sm.SetState(ApplicationStates().Running)
+	context.addApplicationToContext(app1)
+	context.addApplicationToContext(app2)
+	context.addApplicationToContext(app3)
+
+	appIDs := func(apps []*Application) []string {
+		ids := make([]string, 0, len(apps))
+		for _, app := range apps {
+			ids = append(ids, app.GetApplicationID())
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	assert.DeepEqual(t, appIDs(context.GetApplications(AppFilter{})), []string{appID1, appID2, appID3})
+	// queue and user
+	assert.DeepEqual(t, appIDs(context.GetApplications(AppFilter{Queue: "root.a", User: "alice"})), []string{appID1})
+	assert.DeepEqual(t, appIDs(context.GetApplications(AppFilter{Queue: "root.b", User: "bob"})), []string{})
+	// state only
+	assert.DeepEqual(t, appIDs(context.GetApplications(AppFilter{State: ApplicationStates().New})), []string{appID1, appID2})
+	assert.DeepEqual(t, appIDs(context.GetApplications(AppFilter{State: ApplicationStates().Running})), []string{appID3})
+	// tags only
+	assert.DeepEqual(t, appIDs(context.GetApplications(AppFilter{Tags: map[string]string{"env": "prod"}})), []string{appID1, appID3})
+	assert.DeepEqual(t, appIDs(context.GetApplications(AppFilter{Tags: map[string]string{"env": "prod", "team": "ml"}})), []string{appID3})
+	assert.DeepEqual(t, appIDs(context.GetApplications(AppFilter{Tags: map[string]string{"team": ""}})), []string{})
+	// all combined
+	assert.DeepEqual(t, appIDs(context.GetApplications(AppFilter{Queue: "root.b", User: "alice", State: ApplicationStates().Running, Tags: map[string]string{"env": "prod"}})), []string{appID3})
+}
+
+func TestUpdateNodeSchedulableEvent(t *testing.T) {
+	recorder := setTestRecorder(t)
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.EmitNodeSchedulableEvents = true
+	})
+
+	context := initContextForTest()
+	cordoned := nodeForTest(Host1, "10G", "10")
+	cordoned.Spec.Unschedulable = true
+	context.schedulerCache.UpdateNode(cordoned)
+
+	// staying cordoned does not emit an event
+	context.updateNode(nil, cordoned.DeepCopy())
+	assert.Equal(t, len(recorder.Events), 0)
+
+	schedulable := cordoned.DeepCopy()
+	schedulable.Spec.Unschedulable = false
+	context.updateNode(cordoned, schedulable)
+	select {
+	case event := <-recorder.Events:
+		assert.Assert(t, strings.Contains(event, "NodeSchedulable"), "unexpected event: %s", event)
+		assert.Assert(t, strings.Contains(event, Host1), "unexpected event: %s", event)
+	default:
+		t.Fatal("node schedulable event was not emitted")
+	}
+
+	// no event if disabled
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.EmitNodeSchedulableEvents = false
+	})
+	context.updateNode(nil, cordoned.DeepCopy())
+	context.updateNode(cordoned, schedulable.DeepCopy())
+	assert.Equal(t, len(recorder.Events), 0)
+}
+
+func TestGetTaskResource(t *testing.T) {
+	context := initContextForTest()
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+	pod := newPodHelper(pod1Name, "default", pod1UID, "", appID1, v1.PodPending)
+	pod.Spec.Containers = []v1.Container{{
+		Name: "container-01",
+		Resources: v1.ResourceRequirements{
+			Requests: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("500m"),
+				v1.ResourceMemory: resource.MustParse("1G"),
+			},
+		},
+	}}
+	app.addTask(NewTask(pod1UID, app, context, pod))
+
+	res, err := context.GetTaskResource(appID1, pod1UID)
+	assert.NilError(t, err)
+	assert.Equal(t, res.Resources[siCommon.CPU].Value, int64(500))
+	assert.Equal(t, res.Resources[siCommon.Memory].Value, int64(1000*1000*1000))
+	assert.Equal(t, res.Resources["pods"].Value, int64(1))
+
+	_, err = context.GetTaskResource(appID1, "unknown")
+	assert.ErrorContains(t, err, "is not found")
+	_, err = context.GetTaskResource(appID2, pod1UID)
+	assert.ErrorContains(t, err, "is not found")
+}
+
+func TestListDrainingNodes(t *testing.T) {
+	context, apiProvider := initContextAndAPIProviderForTest()
+	apiProvider.MockSchedulerAPIUpdateNodeFn(func(request *si.NodeRequest) error {
+		return nil
+	})
+	nodes := []*v1.Node{
+		nodeForTest("host0001", "10G", "10"),
+		nodeForTest("host0002", "10G", "10"),
+		nodeForTest("host0003", "10G", "10"),
+	}
+	for _, node := range nodes {
+		context.schedulerCache.UpdateNode(node)
+	}
+	assert.Equal(t, len(context.ListDrainingNodes()), 0)
+
+	errs := context.DrainNodes([]string{"host0003", "host0001"})
+	assert.Equal(t, len(errs), 0)
+	assert.DeepEqual(t, context.ListDrainingNodes(), []string{"host0001", "host0003"})
+
+	// enabled nodes are no longer draining
+	assert.NilError(t, context.enableNode(nodes[0]))
+	assert.DeepEqual(t, context.ListDrainingNodes(), []string{"host0003"})
+
+	// removed nodes are no longer draining
+	context.schedulerCache.RemoveNode(nodes[2])
+	assert.Equal(t, len(context.ListDrainingNodes()), 0)
+}
+
+func TestAddTaskPodOverhead(t *testing.T) {
+	context := initContextForTest()
+	context.AddApplication(&AddApplicationRequest{
+		Metadata: ApplicationMetadata{
+			ApplicationID: appID1,
+			QueueName:     "root.a",
+			User:          "test-user",
+		},
+	})
+	pod := newPodHelper(pod1Name, "default", pod1UID, "", appID1, v1.PodPending)
+	pod.Spec.Containers = []v1.Container{{
+		Name: "container-01",
+		Resources: v1.ResourceRequirements{
+			Requests: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("500m"),
+				v1.ResourceMemory: resource.MustParse("1G"),
+			},
+		},
+	}}
+	pod.Spec.Overhead = v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse("250m"),
+		v1.ResourceMemory: resource.MustParse("100M"),
+	}
+	task := context.AddTask(&AddTaskRequest{
+		Metadata: TaskMetadata{
+			ApplicationID: appID1,
+			TaskID:        pod1UID,
+			Pod:           pod,
+		},
+	})
+	assert.Assert(t, task != nil)
+
+	res, err := context.GetTaskResource(appID1, pod1UID)
+	assert.NilError(t, err)
+	assert.Equal(t, res.Resources[siCommon.CPU].Value, int64(750))
+	assert.Equal(t, res.Resources[siCommon.Memory].Value, int64(1100*1000*1000))
+	assert.Equal(t, res.Resources["pods"].Value, int64(1))
+}
+
+func TestGetApplicationScheduleAttempts(t *testing.T) {
+	context := initContextForTest()
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+	app.sm.SetState(ApplicationStates().Running)
+
+	// nothing to schedule: no attempt is counted
+	app.Schedule()
+	assert.Equal(t, context.GetApplicationScheduleAttempts(appID1), int64(0))
+
+	pod := newPodHelper(pod1Name, "default", pod1UID, "", appID1, v1.PodPending)
+	task := NewTask(pod1UID, app, context, pod)
+	app.addTask(task)
+	assert.Equal(t, context.GetApplicationScheduleAttempts(appID1), int64(0))
+
+	// no capacity: the task never gets bound, every call counts as an attempt
+	for i := 1; i <= 3; i++ {
+		app.Schedule()
+		assert.Equal(t, context.GetApplicationScheduleAttempts(appID1), int64(i))
+	}
+
+	task.sm.SetState(TaskStates().Bound)
+	task.postTaskBound()
+	assert.Equal(t, app.GetScheduleAttempts(), int64(0))
+	// the bound task is not tried again
+	app.Schedule()
+	assert.Equal(t, app.GetScheduleAttempts(), int64(0))
+	assert.Equal(t, context.GetApplicationScheduleAttempts("non-existing-app"), int64(0))
+}
+
+func TestDeletePodVerifyExists(t *testing.T) {
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.VerifyPodDeletes = true
+	})
+
+	const pod2UID = "task00002"
+	const pod2Name = "my-pod-2"
+	context, apiProvider := initContextAndAPIProviderForTest()
+	pod1 := newPodHelper(pod1Name, "default", pod1UID, fakeNodeName, appID1, v1.PodRunning)
+	pod2 := newPodHelper(pod2Name, "default", pod2UID, fakeNodeName, appID1, v1.PodRunning)
+	context.schedulerCache.UpdateNode(nodeForTest(fakeNodeName, "10G", "10"))
+	context.AddPod(pod1)
+	context.AddPod(pod2)
+	app := context.GetApplication(appID1)
+	assert.Assert(t, app != nil)
+	for _, taskID := range []string{pod1UID, pod2UID} {
+		task, err := app.GetTask(taskID)
+		assert.NilError(t, err)
+		task.sm.SetState(TaskStates().Bound)
+	}
+
+	// pod1 is still known to the informer: the delete is ignored
+	apiProvider.GetPodListerMock().AddPod(pod1)
+	context.DeletePod(pod1)
+	_, ok := context.schedulerCache.GetPod(pod1UID)
+	assert.Assert(t, ok, "pod1 was removed from the cache")
+	task1, err := app.GetTask(pod1UID)
+	assert.NilError(t, err)
+	assert.Equal(t, task1.GetTaskState(), TaskStates().Bound)
+
+	// pod2 is gone from the informer: the delete is processed
+	context.DeletePod(pod2)
+	_, ok = context.schedulerCache.GetPod(pod2UID)
+	assert.Assert(t, !ok, "pod2 is still present")
+
+	// verification disabled: the delete is processed even if the pod still exists
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.VerifyPodDeletes = false
+	})
+	context.DeletePod(pod1)
+	_, ok = context.schedulerCache.GetPod(pod1UID)
+	assert.Assert(t, !ok, "pod1 is still present")
+}
+
+func TestAddTaskOrigin(t *testing.T) {
+	context := initContextForTest()
+	context.AddApplication(&AddApplicationRequest{
+		Metadata: ApplicationMetadata{
+			ApplicationID: appID1,
+			QueueName:     "root.a",
+			User:          "test-user",
+		},
+	})
+	running := newPodHelper("running-pod", "default", "running-uid", fakeNodeName, appID1, v1.PodRunning)
+	task := context.AddTask(&AddTaskRequest{
+		Metadata: TaskMetadata{
+			ApplicationID: appID1,
+			TaskID:        "running-uid",
+			Pod:           running,
+		},
+	})
+	assert.Assert(t, task != nil)
+	assert.Equal(t, task.GetOrigin(), TaskOriginRecovered)
+
+	pending := newPodHelper("pending-pod", "default", "pending-uid", "", appID1, v1.PodPending)
+	task = context.AddTask(&AddTaskRequest{
+		Metadata: TaskMetadata{
+			ApplicationID: appID1,
+			TaskID:        "pending-uid",
+			Pod:           pending,
+		},
+	})
+	assert.Assert(t, task != nil)
+	assert.Equal(t, task.GetOrigin(), TaskOriginNew)
+}
+
+// regardingRecorder records the object each event is published on
+type regardingRecorder struct {
+	objects []runtime.Object
+	notes   []string
+}
+
+func (r *regardingRecorder) Eventf(regarding runtime.Object, _ runtime.Object, _, _, _, note string, args ...interface{}) {
+	r.objects = append(r.objects, regarding)
+	r.notes = append(r.notes, fmt.Sprintf(note, args...))
+}
+
+func TestPublishQueueEvents(t *testing.T) {
+	recorder := &regardingRecorder{}
+	events.SetRecorder(recorder)
+	defer events.SetRecorder(k8sEvents.NewFakeRecorder(1024))
+
+	context := initContextForTest()
+	lister, ok := context.apiProvider.GetAPIs().NamespaceInformer.Lister().(*test.MockNamespaceLister)
+	assert.Assert(t, ok)
+	lister.Add(&v1.Namespace{
+		ObjectMeta: apis.ObjectMeta{
+			Name: "yunikorn",
+		},
+	})
+	eventRecords := []*si.EventRecord{{
+		Type:              si.EventRecord_QUEUE,
+		EventChangeType:   si.EventRecord_ADD,
+		EventChangeDetail: si.EventRecord_DETAILS_NONE,
+		ObjectID:          "root.test",
+		Message:           "queue added",
+	}}
+
+	// no target configured: the event is dropped
+	context.PublishEvents(eventRecords)
+	assert.Equal(t, len(recorder.objects), 0)
+
+	// unknown target namespace: the event is dropped
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.QueueEventTargetNamespace = "unknown"
+	})
+	context.PublishEvents(eventRecords)
+	assert.Equal(t, len(recorder.objects), 0)
+
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.QueueEventTargetNamespace = "yunikorn"
+	})
+	context.PublishEvents(eventRecords)
+	assert.Equal(t, len(recorder.objects), 1)
+	namespace, ok := recorder.objects[0].(*v1.Namespace)
+	assert.Assert(t, ok, "event not published on a namespace")
+	assert.Equal(t, namespace.Name, "yunikorn")
+	assert.Equal(t, recorder.notes[0], "queue root.test: queue added")
+}
+
+func TestGetApplicationOriginPod(t *testing.T) {
+	context := initContextForTest()
+	pod1 := newPodHelper(pod1Name, "default", pod1UID, "", appID1, v1.PodPending)
+	pod2 := newPodHelper("my-pod-2", "default", "task00002", "", appID1, v1.PodPending)
+	context.AddPod(pod1)
+	context.AddPod(pod2)
+	namespace, name := context.GetApplicationOriginPod(appID1)
+	assert.Equal(t, namespace, "default")
+	assert.Equal(t, name, pod1Name)
+
+	namespace, name = context.GetApplicationOriginPod("non-existing-app")
+	assert.Equal(t, namespace, "")
+	assert.Equal(t, name, "")
+}
+
+func TestUpdateNodeDebounce(t *testing.T) {
+	// the delay never expires in the test: the delayed updates are sent or cancelled explicitly
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.NodeUpdateDebounce = time.Hour
+	})
+
+	context, apiProvider := initContextAndAPIProviderForTest()
+	t.Cleanup(context.Stop)
+	updates := make([]*si.NodeInfo, 0)
+	apiProvider.MockSchedulerAPIUpdateNodeFn(func(request *si.NodeRequest) error {
+		for _, node := range request.Nodes {
+			if node.Action == si.NodeInfo_UPDATE {
+				updates = append(updates, node)
+			}
+		}
+		return nil
+	})
+	pendingUpdates := func() int {
+		context.nodeUpdatesLock.Lock()
+		defer context.nodeUpdatesLock.Unlock()
+		return len(context.nodeUpdates)
+	}
+
+	context.updateNodeInternal(nodeForTest(Host1, "10G", "10"), false)
+	context.updateNodeInternal(nodeForTest(Host1, "11G", "10"), false)
+	context.updateNodeInternal(nodeForTest(Host1, "12G", "10"), false)
+	context.updateNodeInternal(nodeForTest(Host1, "13G", "10"), false)
+	assert.Equal(t, len(updates), 0, "node update was not delayed")
+	assert.Equal(t, pendingUpdates(), 1, "node updates were not collapsed")
+
+	// the delayed update sends the latest resources
+	context.cancelNodeResourcesUpdate(Host1)
+	context.sendDelayedNodeResources(Host1)
+	assert.Equal(t, len(updates), 1)
+	assert.Equal(t, updates[0].NodeID, Host1)
+	assert.Equal(t, updates[0].SchedulableResource.Resources[siCommon.Memory].Value, int64(13*1000*1000*1000))
+
+	// deleting the node cancels its delayed update
+	context.updateNodeInternal(nodeForTest(Host1, "14G", "10"), false)
+	context.updateNodeInternal(nodeForTest(Host2, "10G", "10"), false)
+	context.updateNodeInternal(nodeForTest(Host2, "11G", "10"), false)
+	assert.Equal(t, pendingUpdates(), 2)
+	context.deleteNodeInternal(nodeForTest(Host1, "14G", "10"))
+	assert.Equal(t, pendingUpdates(), 1)
+
+	// stopping cancels all delayed updates
+	context.Stop()
+	assert.Equal(t, pendingUpdates(), 0)
+	assert.Equal(t, len(updates), 1)
+}
+
+func TestGetTasksByPriorityClass(t *testing.T) {
+	context := initContextForTest()
+	app1 := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	app2 := NewApplication(appID2, "root.b", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app1)
+	context.addApplicationToContext(app2)
+
+	pod1 := newPodHelper("pod1", "default", "task0001", "", appID1, v1.PodPending)
+	pod1.Spec.PriorityClassName = "high"
+	pod2 := newPodHelper("pod2", "default", "task0002", "", appID1, v1.PodPending)
+	pod2.Spec.PriorityClassName = "low"
+	pod3 := newPodHelper("pod3", "default", "task0003", "", appID2, v1.PodPending)
+	pod3.Spec.PriorityClassName = "high"
+	task1 := NewTask("task0001", app1, context, pod1)
+	task2 := NewTask("task0002", app1, context, pod2)
+	task3 := NewTask("task0003", app2, context, pod3)
+	app1.addTask(task1)
+	app1.addTask(task2)
+	app2.addTask(task3)
+
+	tasks := context.GetTasksByPriorityClass("high")
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].GetTaskID() < tasks[j].GetTaskID()
+	})
+	assert.Equal(t, len(tasks), 2)
+	assert.Equal(t, tasks[0], task1)
+	assert.Equal(t, tasks[1], task3)
+	tasks = context.GetTasksByPriorityClass("low")
+	assert.Equal(t, len(tasks), 1)
+	assert.Equal(t, tasks[0], task2)
+	assert.Equal(t, len(context.GetTasksByPriorityClass("unknown")), 0)
+}
+
+func TestAnnotateEstimatedWait(t *testing.T) {
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.AnnotateEstimatedWait = true
+	})
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	setTestClock(t, func() time.Time { return now })
+
+	context, apiProvider := initContextAndAPIProviderForTest()
+	type patch struct {
+		podName     string
+		annotations map[string]string
+	}
+	patched := make(chan patch, 2)
+	apiProvider.MockPatchPodAnnotationsFn(func(pod *v1.Pod, annotations map[string]string) error {
+		patched <- patch{podName: pod.Name, annotations: annotations}
+		return nil
+	})
+
+	// no bindings yet: no estimate, no annotation
+	context.AddPod(newPodHelper("pod-1", "default", "task0001", "", appID1, v1.PodPending))
+	_, ok := context.EstimateApplicationWait(appID1)
+	assert.Assert(t, !ok, "estimate without any bindings")
+
+	// two bindings in the window of another queue and two unscheduled tasks in the queue
+	bound := NewApplication(appID3, "root.other", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(bound)
+	for _, taskID := range []string{"task1001", "task1002"} {
+		task := NewTask(taskID, bound, context, newPodHelper("pod-"+taskID, "default", taskID, "", appID3, v1.PodPending))
+		bound.addTask(task)
+		task.sm.SetState(TaskStates().Allocated)
+		assert.NilError(t, task.handle(NewBindTaskEvent(appID3, taskID)))
+	}
+	context.AddPod(newPodHelper("pod-2", "default", "task0002", "", appID2, v1.PodPending))
+	select {
+	case p := <-patched:
+		assert.Equal(t, p.podName, "pod-2")
+		assert.DeepEqual(t, p.annotations, map[string]string{constants.AnnotationEstimatedWait: "5m0s"})
+	case <-time.After(time.Second):
+		t.Fatal("pod was not patched with the estimated wait annotation")
+	}
+	wait, ok := context.EstimateApplicationWait(appID2)
+	assert.Assert(t, ok)
+	assert.Equal(t, wait, 5*time.Minute)
+
+	// only the first pod of the application is annotated
+	context.AddPod(newPodHelper("pod-3", "default", "task0003", "", appID2, v1.PodPending))
+	select {
+	case p := <-patched:
+		t.Fatalf("unexpected patch of pod %s", p.podName)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestGetApplicationsNeedingRecovery(t *testing.T) {
+	context := initContextForTest()
+	context.schedulerCache.UpdateNode(nodeForTest(Host1, "10G", "10"))
+	assert.Equal(t, len(context.GetApplicationsNeedingRecovery()), 0)
+
+	// recovered pods on a node, no allocation confirmed by the core yet
+	context.AddPod(newPodHelper("pod-1", "default", "task0001", Host1, appID1, v1.PodRunning))
+	context.AddPod(newPodHelper("pod-2", "default", "task0002", Host1, appID2, v1.PodRunning))
+	// new pod that is not assigned to a node
+	context.AddPod(newPodHelper("pod-3", "default", "task0003", "", appID3, v1.PodPending))
+
+	apps := context.GetApplicationsNeedingRecovery()
+	sort.Slice(apps, func(i, j int) bool {
+		return apps[i].GetApplicationID() < apps[j].GetApplicationID()
+	})
+	assert.Equal(t, len(apps), 2)
+	assert.Equal(t, apps[0].GetApplicationID(), appID1)
+	assert.Equal(t, apps[1].GetApplicationID(), appID2)
+
+	// the core confirms the allocation of the pod of app1
+	task, err := apps[0].GetTask("task0001")
+	assert.NilError(t, err)
+	task.MarkPreviouslyAllocated("task0001", Host1)
+	apps = context.GetApplicationsNeedingRecovery()
+	assert.Equal(t, len(apps), 1)
+	assert.Equal(t, apps[0].GetApplicationID(), appID2)
+}
+
+func TestUpdatePodTerminating(t *testing.T) {
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.ReleaseTerminatingPods = true
+	})
+
+	context, apiProvider := initContextAndAPIProviderForTest()
+	dispatcher.Start()
+	dispatcher.RegisterEventHandler("TestAppHandler", dispatcher.EventTypeApp, context.ApplicationEventHandler())
+	dispatcher.RegisterEventHandler("TestTaskHandler", dispatcher.EventTypeTask, context.TaskEventHandler())
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+	var released atomic.Bool
+	apiProvider.MockSchedulerAPIUpdateAllocationFn(func(request *si.AllocationRequest) error {
+		if request.Releases != nil && len(request.Releases.AllocationsToRelease) > 0 {
+			released.Store(true)
+		}
+		return nil
+	})
+
+	context.schedulerCache.UpdateNode(nodeForTest(Host1, "10G", "10"))
+	pod := newPodHelper(pod1Name, "default", pod1UID, Host1, appID1, v1.PodRunning)
+	context.AddPod(pod)
+	app := context.GetApplication(appID1)
+	assert.Assert(t, app != nil)
+	task, err := app.GetTask(pod1UID)
+	assert.NilError(t, err)
+	task.MarkPreviouslyAllocated(pod1UID, Host1)
+
+	// an update without a new deletion timestamp does not release the task
+	context.UpdatePod(pod, pod.DeepCopy())
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, task.GetTaskState(), TaskStates().Bound)
+
+	terminating := pod.DeepCopy()
+	now := apis.Now()
+	terminating.DeletionTimestamp = &now
+	context.UpdatePod(pod, terminating)
+	err = utils.WaitForCondition(func() bool {
+		return task.GetTaskState() == TaskStates().Completed
+	}, 10*time.Millisecond, 3*time.Second)
+	assert.NilError(t, err, "task of terminating pod was not completed")
+	assert.Assert(t, released.Load(), "allocation of terminating pod was not released")
+	// the pod still occupies the node until it is removed
+	_, ok := context.schedulerCache.GetPod(pod1UID)
+	assert.Assert(t, ok, "terminating pod was removed from the cache")
+
+	// the completed task is removed while scheduling, later updates of the pod must not recover it
+	app.sm.SetState(ApplicationStates().Running)
+	app.Schedule()
+	_, err = app.GetTask(pod1UID)
+	assert.Assert(t, err != nil, "completed task was not removed")
+	context.UpdatePod(terminating, terminating.DeepCopy())
+	_, err = app.GetTask(pod1UID)
+	assert.Assert(t, err != nil, "task of released pod was recovered")
+
+	// once the pod is gone a pod with the same UID is tracked again
+	context.DeletePod(terminating)
+	context.AddPod(pod.DeepCopy())
+	_, err = context.GetApplication(appID1).GetTask(pod1UID)
+	assert.NilError(t, err, "task of re-added pod not created")
+}
+
+func TestGetBindingQueueLength(t *testing.T) {
+	context := initContextForTest()
+	assert.Equal(t, context.GetBindingQueueLength(), 0)
+
+	for _, podKey := range []string{"UID-00001", "UID-00002", "UID-00003"} {
+		context.AddPendingPodAllocation(podKey, Host1)
+	}
+	// pending allocations are not waiting for a bind yet
+	assert.Equal(t, context.GetBindingQueueLength(), 0)
+
+	assert.Assert(t, context.StartPodAllocation("UID-00001", Host1))
+	assert.Assert(t, context.StartPodAllocation("UID-00002", Host1))
+	assert.Equal(t, context.GetBindingQueueLength(), 2)
+
+	context.RemovePodAllocation("UID-00001")
+	assert.Equal(t, context.GetBindingQueueLength(), 1)
+}
+
+func TestScheduleTaskInvalidMinMember(t *testing.T) {
+	recorder := setTestRecorder(t)
+
+	context := initContextForTest()
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+	app.sm.SetState(ApplicationStates().Running)
+	valid := newPodHelper("pod-1", "default", "task0001", "", appID1, v1.PodPending)
+	valid.Annotations = map[string]string{constants.AnnotationTaskGroupMinMember: "2"}
+	invalid := newPodHelper("pod-2", "default", "task0002", "", appID1, v1.PodPending)
+	invalid.Annotations = map[string]string{constants.AnnotationTaskGroupMinMember: "zero"}
+	validTask := context.AddTask(&AddTaskRequest{
+		Metadata: TaskMetadata{ApplicationID: appID1, TaskID: "task0001", Pod: valid},
+	})
+	invalidTask := context.AddTask(&AddTaskRequest{
+		Metadata: TaskMetadata{ApplicationID: appID1, TaskID: "task0002", Pod: invalid},
+	})
+	// the malformed value fails the task once on creation
+	assert.Equal(t, invalidTask.GetTaskState(), TaskStates().Failed)
+
+	for i := 0; i < 3; i++ {
+		app.Schedule()
+	}
+	assert.Equal(t, validTask.GetTaskState(), TaskStates().Pending)
+	assert.Equal(t, invalidTask.GetTaskState(), TaskStates().Failed)
+	rejections := 0
+	for len(recorder.Events) > 0 {
+		event := <-recorder.Events
+		if strings.Contains(event, constants.AnnotationTaskGroupMinMember) {
+			assert.Assert(t, strings.Contains(event, "InvalidTaskGroupMinMember"), "unexpected event: %s", event)
+			rejections++
+		}
+	}
+	assert.Equal(t, rejections, 1, "rejection event for the invalid min member not found once")
+}
+
+func TestGetApplicationsWithStuckTasks(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	setTestClock(t, func() time.Time { return start })
+
+	context := initContextForTest()
+	app1 := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	app2 := NewApplication(appID2, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app1)
+	context.addApplicationToContext(app2)
+	addTask := func(app *Application, taskID string, age time.Duration, state string) *Task {
+		pod := newPodHelper(taskID, "default", taskID, "", app.GetApplicationID(), v1.PodPending)
+		pod.CreationTimestamp = apis.NewTime(start.Add(-age))
+		task := NewTask(taskID, app, context, pod)
+		task.sm.SetState(state)
+		app.addTask(task)
+		return task
+	}
+	old1 := addTask(app1, "task0001", 20*time.Minute, TaskStates().Pending)
+	addTask(app1, "task0002", time.Minute, TaskStates().Pending)
+	addTask(app1, "task0003", time.Hour, TaskStates().Bound)
+	addTask(app2, "task0004", time.Minute, TaskStates().Pending)
+
+	stuck := context.GetApplicationsWithStuckTasks(10 * time.Minute)
+	assert.Equal(t, len(stuck), 1)
+	assert.Equal(t, len(stuck[appID1]), 1)
+	assert.Equal(t, stuck[appID1][0], old1)
+
+	// time passes: all pending tasks are stuck
+	setTestClock(t, func() time.Time { return start.Add(time.Hour) })
+	stuck = context.GetApplicationsWithStuckTasks(10 * time.Minute)
+	assert.Equal(t, len(stuck), 2)
+	assert.Equal(t, len(stuck[appID1]), 2)
+	assert.Equal(t, len(stuck[appID2]), 1)
+}
+
+func TestAddApplicationDefaultGroups(t *testing.T) {
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.DefaultGroups = []string{"staff", "analysts"}
+	})
+
+	context := initContextForTest()
+	app := context.AddApplication(&AddApplicationRequest{
+		Metadata: ApplicationMetadata{
+			ApplicationID: appID1,
+			QueueName:     "root.a",
+			User:          "testuser",
+		},
+	})
+	assert.DeepEqual(t, app.groups, []string{"staff", "analysts"})
+
+	// groups of the identity are not replaced
+	app = context.AddApplication(&AddApplicationRequest{
+		Metadata: ApplicationMetadata{
+			ApplicationID: appID2,
+			QueueName:     "root.a",
+			User:          "testuser",
+			Groups:        []string{"dev"},
+		},
+	})
+	assert.DeepEqual(t, app.groups, []string{"dev"})
+}
+
+func TestForceRescheduleApplication(t *testing.T) {
+	const pod2UID = "task00002"
+	const pod2Name = "my-pod-2"
+	context, apiProvider := initContextAndAPIProviderForTest()
+	dispatcher.Start()
+	dispatcher.RegisterEventHandler("TestTaskHandler", dispatcher.EventTypeTask, context.TaskEventHandler())
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+
+	var lock locking.Mutex
+	releasedAsks := make([]string, 0)
+	releasedAllocs := make([]string, 0)
+	asks := make([]string, 0)
+	allocs := make(map[string]string)
+	apiProvider.MockSchedulerAPIUpdateAllocationFn(func(request *si.AllocationRequest) error {
+		lock.Lock()
+		defer lock.Unlock()
+		if request.Releases != nil {
+			for _, ask := range request.Releases.AllocationAsksToRelease {
+				releasedAsks = append(releasedAsks, ask.AllocationKey)
+			}
+			for _, alloc := range request.Releases.AllocationsToRelease {
+				releasedAllocs = append(releasedAllocs, alloc.AllocationKey)
+			}
+		}
+		for _, ask := range request.Asks {
+			asks = append(asks, ask.AllocationKey)
+		}
+		// the core confirms the allocations of bound pods
+		for _, alloc := range request.Allocations {
+			allocs[alloc.AllocationKey] = alloc.NodeID
+			dispatcher.Dispatch(NewAllocateTaskEvent(alloc.ApplicationID, alloc.AllocationKey, alloc.AllocationKey, alloc.NodeID))
+		}
+		return nil
+	})
+	var bindCalled atomic.Bool
+	apiProvider.MockBindFn(func(pod *v1.Pod, hostID string) error {
+		bindCalled.Store(true)
+		return nil
+	})
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, apiProvider.GetAPIs().SchedulerAPI)
+	context.addApplicationToContext(app)
+	app.sm.SetState(ApplicationStates().Running)
+	// a task waiting for an allocation from the core
+	waiting := NewTask(pod1UID, app, context, newPodHelper(pod1Name, "default", pod1UID, "", appID1, v1.PodPending))
+	app.addTask(waiting)
+	waiting.sm.SetState(TaskStates().Scheduling)
+	// a bound task
+	bound := NewTask(pod2UID, app, context, newPodHelper(pod2Name, "default", pod2UID, fakeNodeName, appID1, v1.PodRunning))
+	app.addTask(bound)
+	bound.MarkPreviouslyAllocated(pod2UID, fakeNodeName)
+
+	err := context.ForceRescheduleApplication(appID1)
+	assert.NilError(t, err)
+	// the released ask and allocation are submitted again through the dispatcher
+	err = utils.WaitForCondition(func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(asks) == 1 && len(allocs) == 1 &&
+			waiting.GetTaskState() == TaskStates().Scheduling && bound.GetTaskState() == TaskStates().Bound
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err, "tasks were not re-submitted")
+	lock.Lock()
+	sort.Strings(releasedAsks)
+	assert.DeepEqual(t, releasedAsks, []string{pod1UID, pod2UID})
+	assert.DeepEqual(t, releasedAllocs, []string{pod2UID})
+	assert.DeepEqual(t, asks, []string{pod1UID})
+	assert.DeepEqual(t, allocs, map[string]string{pod2UID: fakeNodeName})
+	lock.Unlock()
+	// the bound pod stays on its node
+	assert.Equal(t, bound.getNodeName(), fakeNodeName)
+	assert.Assert(t, !bindCalled.Load(), "bound pod should not be bound again")
+	for _, task := range []*Task{waiting, bound} {
+		requeued := false
+		for _, transition := range task.GetTransitions() {
+			if transition.Event == RescheduleTask.String() && transition.To == TaskStates().Pending {
+				requeued = true
+			}
+		}
+		assert.Assert(t, requeued, "task %s was not re-queued", task.GetTaskID())
+	}
+
+	err = context.ForceRescheduleApplication("non-existing-app")
+	assert.ErrorContains(t, err, "not found")
+	app.sm.SetState(ApplicationStates().Completed)
+	err = context.ForceRescheduleApplication(appID1)
+	assert.ErrorContains(t, err, "cannot be rescheduled")
+}
+
+func TestAddTaskExceedsNodeCapacity(t *testing.T) {
+	recorder := setTestRecorder(t)
+	context := initContextForTest()
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.FailUnschedulableOversizedPods = true
+	})
+
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+
+	// no nodes known: nothing to compare against
+	task := context.AddTask(&AddTaskRequest{
+		Metadata: TaskMetadata{
+			ApplicationID: appID1,
+			TaskID:        "task0001",
+			Pod:           foreignPod("task0001", "16G", "500m"),
+		},
+	})
+	assert.Equal(t, task.GetTaskState(), TaskStates().New)
+
+	context.schedulerCache.UpdateNode(nodeForTest(Host1, "4G", "2"))
+	context.schedulerCache.UpdateNode(nodeForTest(Host2, "8G", "2"))
+	// fits the largest node
+	task = context.AddTask(&AddTaskRequest{
+		Metadata: TaskMetadata{
+			ApplicationID: appID1,
+			TaskID:        "task0002",
+			Pod:           foreignPod("task0002", "6G", "500m"),
+		},
+	})
+	assert.Equal(t, task.GetTaskState(), TaskStates().New)
+
+	// larger than any node
+	task = context.AddTask(&AddTaskRequest{
+		Metadata: TaskMetadata{
+			ApplicationID: appID1,
+			TaskID:        "task0003",
+			Pod:           foreignPod("task0003", "16G", "500m"),
+		},
+	})
+	assert.Equal(t, task.GetTaskState(), TaskStates().Failed)
+	found := false
+	for len(recorder.Events) > 0 {
+		event := <-recorder.Events
+		if strings.Contains(event, "PodExceedsNodeCapacity") && strings.Contains(event, "unschedulable: exceeds node capacity") {
+			found = true
+		}
+	}
+	assert.Assert(t, found, "node capacity event not found")
+
+	// a resource that no node has counts as zero capacity
+	gpuPod := foreignPod("task0005", "1G", "500m")
+	gpuPod.Spec.Containers[0].Resources.Requests["nvidia.com/gpu"] = resource.MustParse("1")
+	task = context.AddTask(&AddTaskRequest{
+		Metadata: TaskMetadata{
+			ApplicationID: appID1,
+			TaskID:        "task0005",
+			Pod:           gpuPod,
+		},
+	})
+	assert.Equal(t, task.GetTaskState(), TaskStates().Failed)
+
+	// check disabled
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.FailUnschedulableOversizedPods = false
+	})
+	task = context.AddTask(&AddTaskRequest{
+		Metadata: TaskMetadata{
+			ApplicationID: appID1,
+			TaskID:        "task0004",
+			Pod:           foreignPod("task0004", "16G", "500m"),
+		},
+	})
+	assert.Equal(t, task.GetTaskState(), TaskStates().New)
+}
+
+func TestGetTaskCreationToBindLatency(t *testing.T) {
+	created := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	setTestClock(t, func() time.Time { return created.Add(time.Second) })
+
+	context := initContextForTest()
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+	app.sm.SetState(ApplicationStates().Running)
+	pod := newPodHelper(pod1Name, "default", pod1UID, "", appID1, v1.PodPending)
+	pod.CreationTimestamp = apis.NewTime(created)
+	task := NewTask(pod1UID, app, context, pod)
+	app.addTask(task)
+	task.sm.SetState(TaskStates().Allocated)
+
+	_, ok := context.GetTaskCreationToBindLatency(appID1, pod1UID)
+	assert.Assert(t, !ok, "unbound task should not report a latency")
+
+	setTestClock(t, func() time.Time { return created.Add(42 * time.Second) })
+	err := task.handle(NewBindTaskEvent(appID1, pod1UID))
+	assert.NilError(t, err)
+	assert.Equal(t, task.GetTaskState(), TaskStates().Bound)
+	latency, ok := context.GetTaskCreationToBindLatency(appID1, pod1UID)
+	assert.Assert(t, ok)
+	assert.Equal(t, latency, 42*time.Second)
+
+	_, ok = context.GetTaskCreationToBindLatency(appID1, "non-existing-task")
+	assert.Assert(t, !ok)
+}
+
+func TestTaskAutoRemoveOnCompletion(t *testing.T) {
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.AutoRemoveCompletedTasks = true
+	})
+
+	context := initContextForTest()
+	dispatcher.Start()
+	dispatcher.RegisterEventHandler("TestAppHandler", dispatcher.EventTypeApp, context.ApplicationEventHandler())
+	dispatcher.RegisterEventHandler("TestTaskHandler", dispatcher.EventTypeTask, context.TaskEventHandler())
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+
+	app := context.AddApplication(&AddApplicationRequest{
+		Metadata: ApplicationMetadata{
+			ApplicationID: appID,
+			QueueName:     queue,
+			User:          "test-user",
+		},
+	})
+	task := context.AddTask(&AddTaskRequest{
+		Metadata: TaskMetadata{
+			ApplicationID: appID,
+			TaskID:        pod1UID,
+			Pod:           newPodHelper(pod1Name, namespace, pod1UID, fakeNodeName, appID, v1.PodRunning),
+		},
+	})
+	task.sm.SetState(TaskStates().Bound)
+
+	// the task is removed without a scheduling cycle of the application
+	context.NotifyTaskComplete(appID, pod1UID)
+	err := utils.WaitForCondition(func() bool {
+		_, err := app.GetTask(pod1UID)
+		return err != nil
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err)
+	assert.Equal(t, task.GetTaskState(), TaskStates().Completed)
+}
+
+func TestGetApplicationScheduleReadiness(t *testing.T) {
+	context := initContextForTest()
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	app.setTaskGroups([]TaskGroup{{Name: "test-group-1", MinMember: 2}})
+	context.addApplicationToContext(app)
+
+	app.sm.SetState(ApplicationStates().Reserving)
+	ready, reason := context.GetApplicationScheduleReadiness(appID1)
+	assert.Assert(t, !ready)
+	assert.Equal(t, reason, "waiting for the gang to be gathered")
+
+	app.sm.SetState(ApplicationStates().Running)
+	ready, reason = context.GetApplicationScheduleReadiness(appID1)
+	assert.Assert(t, ready)
+	assert.Equal(t, reason, "")
+
+	// a paused application is never ready and is not scheduled
+	assert.NilError(t, context.SetApplicationPaused(appID1, true))
+	ready, reason = context.GetApplicationScheduleReadiness(appID1)
+	assert.Assert(t, !ready)
+	assert.Equal(t, reason, "application is paused")
+	assert.Assert(t, !app.Schedule())
+	assert.NilError(t, context.SetApplicationPaused(appID1, false))
+	assert.ErrorContains(t, context.SetApplicationPaused("non-existing-app", true), "is not found")
+
+	// suspending a parent queue suspends the queue of the application
+	context.SetQueueSuspended("root", true)
+	assert.Assert(t, context.IsQueueSuspended("root.a"))
+	ready, reason = context.GetApplicationScheduleReadiness(appID1)
+	assert.Assert(t, !ready)
+	assert.Equal(t, reason, "queue root is suspended")
+	context.SetQueueSuspended("root", false)
+	assert.Assert(t, !context.IsQueueSuspended("root.a"))
+	ready, _ = context.GetApplicationScheduleReadiness(appID1)
+	assert.Assert(t, ready)
+
+	ready, reason = context.GetApplicationScheduleReadiness("non-existing-app")
+	assert.Assert(t, !ready)
+	assert.Equal(t, reason, "application non-existing-app is not found")
+}
+
+func TestAssumePod_FindPodVolumesTransientError(t *testing.T) {
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.VolumeLookupRetries = 3
+	})
+	defer func(backoff time.Duration) { volumeLookupBackoff = backoff }(volumeLookupBackoff)
+	volumeLookupBackoff = time.Millisecond
+
+	// transient errors are retried until the lookup succeeds
+	binder := test.NewVolumeBinderMock()
+	binder.SetFindPodVolumesError(apierrors.NewTimeoutError("volume lookup timed out", 1), 2)
+	context := initAssumePodTest(binder)
+	// the context must stay usable while the lookup is retried
+	lockFree := true
+	binder.SetFindPodVolumesHook(func() {
+		acquired := make(chan struct{})
+		go func() {
+			context.GetApplication(appID1)
+			close(acquired)
+		}()
+		select {
+		case <-acquired:
+		case <-time.After(time.Second):
+			lockFree = false
+		}
+	})
+	err := context.AssumePod(pod1UID, fakeNodeName)
+	assert.NilError(t, err)
+	assert.Equal(t, binder.GetFindPodVolumesCalls(), 3)
+	assert.Assert(t, lockFree, "context locked during the volume lookup")
+	assert.Assert(t, context.schedulerCache.IsAssumedPod(pod1UID))
+	dispatcher.UnregisterAllEventHandlers()
+	dispatcher.Stop()
+
+	// other errors fail immediately
+	binder = test.NewVolumeBinderMock()
+	binder.SetFindPodVolumesError(fmt.Errorf("error getting pod volumes"), 2)
+	context = initAssumePodTest(binder)
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+	err = context.AssumePod(pod1UID, fakeNodeName)
+	assert.Error(t, err, "error getting pod volumes")
+	assert.Equal(t, binder.GetFindPodVolumesCalls(), 1)
+	assert.Assert(t, !context.schedulerCache.IsAssumedPod(pod1UID))
+}
+
+func TestGetNodeTaskDensity(t *testing.T) {
+	context := initContextForTest()
+	context.schedulerCache.UpdateNode(nodeForTest(Host1, "10G", "2"))
+	context.schedulerCache.UpdateNode(nodeForTest(Host2, "10G", "4"))
+	context.schedulerCache.UpdateNode(nodeForTest(fakeNodeName, "10G", "0"))
+	assert.DeepEqual(t, context.GetNodeTaskDensity(), map[string]float64{Host1: 0, Host2: 0})
+
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+	nodes := []string{Host1, Host1, Host1, Host2, fakeNodeName}
+	for i, node := range nodes {
+		taskID := fmt.Sprintf("task%05d", i)
+		task := NewTask(taskID, app, context, newPodHelper("pod-"+taskID, "default", taskID, node, appID1, v1.PodRunning))
+		app.addTask(task)
+		task.MarkPreviouslyAllocated(taskID, node)
+	}
+	// pending tasks are not counted
+	app.addTask(NewTask("pending", app, context, newPodHelper("pending", "default", "pending", "", appID1, v1.PodPending)))
+
+	assert.DeepEqual(t, context.GetNodeTaskDensity(), map[string]float64{Host1: 1.5, Host2: 0.25})
+}
+
+func TestUpdatePodApplicationIDChange(t *testing.T) {
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.AllowAppIDChange = true
+	})
+
+	context := initContextForTest()
+	pod := newPodHelper(pod1Name, "default", pod1UID, "", appID1, v1.PodPending)
+	context.AddPod(pod)
+	oldApp := context.GetApplication(appID1)
+	assert.Assert(t, oldApp != nil)
+	_, err := oldApp.GetTask(pod1UID)
+	assert.NilError(t, err)
+
+	movedPod := pod.DeepCopy()
+	movedPod.Labels[constants.LabelApplicationID] = appID2
+	context.UpdatePod(pod, movedPod)
+	_, err = oldApp.GetTask(pod1UID)
+	assert.ErrorContains(t, err, "doesn't exist")
+	newApp := context.GetApplication(appID2)
+	assert.Assert(t, newApp != nil)
+	task, err := newApp.GetTask(pod1UID)
+	assert.NilError(t, err)
+	assert.Equal(t, task.applicationID, appID2)
+
+	// change ignored when disabled
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.AllowAppIDChange = false
+	})
+	movedAgain := movedPod.DeepCopy()
+	movedAgain.Labels[constants.LabelApplicationID] = appID3
+	context.UpdatePod(movedPod, movedAgain)
+	_, err = newApp.GetTask(pod1UID)
+	assert.NilError(t, err)
+}
+
+func TestGetApplicationBindThroughput(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	now := start
+	setTestClock(t, func() time.Time { return now })
+
+	context := initContextForTest()
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+	app.sm.SetState(ApplicationStates().Running)
+	// bind a task every 10 seconds
+	for i := 0; i < 6; i++ {
+		taskID := fmt.Sprintf("task%05d", i)
+		task := NewTask(taskID, app, context, newPodHelper("pod-"+taskID, "default", taskID, "", appID1, v1.PodPending))
+		app.addTask(task)
+		task.sm.SetState(TaskStates().Allocated)
+		now = start.Add(time.Duration(i*10) * time.Second)
+		assert.NilError(t, task.handle(NewBindTaskEvent(appID1, taskID)))
+	}
+
+	// last bind at 50s, the 30s window covers the binds at 30s, 40s and 50s
+	now = start.Add(55 * time.Second)
+	assert.Equal(t, context.GetApplicationBindThroughput(appID1, 30*time.Second), 0.1)
+	assert.Equal(t, context.GetApplicationBindThroughput(appID1, time.Minute), 0.1)
+	now = start.Add(10 * time.Minute)
+	assert.Equal(t, context.GetApplicationBindThroughput(appID1, time.Minute), 0.0)
+	assert.Equal(t, context.GetApplicationBindThroughput("non-existing-app", time.Minute), 0.0)
+}
+
+func TestValidateState(t *testing.T) {
+	context := initContextForTest()
+	context.schedulerCache.UpdateNode(nodeForTest(Host1, "10G", "10"))
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+
+	// consistent: bound task with its pod cached on a known node
+	pod := newPodHelper(pod1Name, "default", pod1UID, Host1, appID1, v1.PodRunning)
+	context.schedulerCache.UpdatePod(pod)
+	task := NewTask(pod1UID, app, context, pod)
+	app.addTask(task)
+	task.MarkPreviouslyAllocated(pod1UID, Host1)
+	assert.DeepEqual(t, context.ValidateState(), []string{})
+
+	// bound task without a cached pod
+	uncached := NewTask("uncached", app, context, newPodHelper("uncached", "default", "uncached", Host1, appID1, v1.PodRunning))
+	app.addTask(uncached)
+	uncached.MarkPreviouslyAllocated("uncached", Host1)
+	// bound task on an unknown node
+	pod = newPodHelper("lost-node", "default", "lost-node", "missing-node", appID1, v1.PodRunning)
+	context.schedulerCache.UpdatePod(pod)
+	lostNode := NewTask("lost-node", app, context, pod)
+	app.addTask(lostNode)
+	lostNode.MarkPreviouslyAllocated("lost-node", "missing-node")
+	// task referencing an application that is not in the context
+	otherApp := NewApplication(appID2, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	app.addTask(NewTask("orphan", otherApp, context, newPodHelper("orphan", "default", "orphan", "", appID2, v1.PodPending)))
+	// allocation without a task
+	context.schedulerCache.AddPendingPodAllocation("ghost", Host1)
+
+	assert.DeepEqual(t, context.ValidateState(), []string{
+		"allocation of pod ghost on node " + Host1 + " has no task",
+		"task lost-node of application app00001 is bound to node missing-node which is not in the cache",
+		"task orphan of application app00001 references application app00002 which is not in the context",
+		"task uncached of application app00001 is bound but its pod is not in the cache",
+	})
+}
+
+func TestUpdatePodImagePullBackOff(t *testing.T) {
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.ImagePullBackoffTimeout = time.Minute
+	})
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	now := start
+	setTestClock(t, func() time.Time { return now })
+
+	context, apiProvider := initContextAndAPIProviderForTest()
+	var released atomic.Int32
+	apiProvider.MockSchedulerAPIUpdateAllocationFn(func(request *si.AllocationRequest) error {
+		if request.Releases != nil && len(request.Releases.AllocationsToRelease) > 0 {
+			released.Add(1)
+		}
+		return nil
+	})
+	dispatcher.Start()
+	dispatcher.RegisterEventHandler("TestAppHandler", dispatcher.EventTypeApp, context.ApplicationEventHandler())
+	dispatcher.RegisterEventHandler("TestTaskHandler", dispatcher.EventTypeTask, context.TaskEventHandler())
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+
+	context.schedulerCache.UpdateNode(nodeForTest(Host1, "10G", "10"))
+	pod := newPodHelper(pod1Name, "default", pod1UID, Host1, appID1, v1.PodPending)
+	context.AddPod(pod)
+	app := context.GetApplication(appID1)
+	assert.Assert(t, app != nil)
+	task, err := app.GetTask(pod1UID)
+	assert.NilError(t, err)
+	task.MarkPreviouslyAllocated(pod1UID, Host1)
+
+	backOff := pod.DeepCopy()
+	backOff.Status.ContainerStatuses = []v1.ContainerStatus{{
+		Name:  "container-1",
+		State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+	}}
+	context.UpdatePod(pod, backOff)
+	now = start.Add(30 * time.Second)
+	context.UpdatePod(backOff, backOff)
+	assert.Equal(t, task.GetTaskState(), TaskStates().Bound)
+
+	// past the timeout the task is failed and the allocation released
+	now = start.Add(2 * time.Minute)
+	context.UpdatePod(backOff, backOff)
+	err = utils.WaitForCondition(func() bool {
+		return task.GetTaskState() == TaskStates().Failed
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err)
+	assert.Equal(t, released.Load(), int32(1))
+
+	// the failed task is removed while scheduling, later updates of the pod must not recover it
+	app.sm.SetState(ApplicationStates().Running)
+	app.Schedule()
+	_, err = app.GetTask(pod1UID)
+	assert.Assert(t, err != nil, "failed task was not removed")
+	now = start.Add(5 * time.Minute)
+	context.UpdatePod(backOff, backOff)
+	_, err = app.GetTask(pod1UID)
+	assert.Assert(t, err != nil, "task of released pod was recovered")
+	assert.Equal(t, released.Load(), int32(1))
+}
+
+func TestFailTaskIgnoresBoundTask(t *testing.T) {
+	context := initContextForTest()
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+	task := NewTask(pod1UID, app, context, newPodHelper(pod1Name, "default", pod1UID, Host1, appID1, v1.PodRunning))
+	app.addTask(task)
+	task.MarkPreviouslyAllocated(pod1UID, Host1)
+
+	// only the dedicated event fails a bound task
+	err := task.handle(NewFailTaskEvent(appID1, pod1UID, "failed"))
+	assert.Assert(t, err != nil, "bound task failed by fail task event")
+	assert.Equal(t, task.GetTaskState(), TaskStates().Bound)
+	err = task.handle(NewFailBoundTaskEvent(appID1, pod1UID, "failed"))
+	assert.NilError(t, err)
+	assert.Equal(t, task.GetTaskState(), TaskStates().Failed)
+}
+
+func TestGetApplicationByAllocationKey(t *testing.T) {
+	context := initContextForTest()
+	context.schedulerCache.UpdateNode(nodeForTest(Host1, "10G", "10"))
+	context.AddPod(newPodHelper(pod1Name, "default", pod1UID, "", appID1, v1.PodPending))
+	context.AddPod(newPodHelper("other-pod", "default", "other-uid", "", appID2, v1.PodPending))
+	_, ok := context.GetApplicationByAllocationKey(pod1UID)
+	assert.Assert(t, !ok, "application returned for task without allocation")
+
+	app := context.GetApplication(appID1)
+	task, err := app.GetTask(pod1UID)
+	assert.NilError(t, err)
+	task.MarkPreviouslyAllocated(pod1UID, Host1)
+	owner, ok := context.GetApplicationByAllocationKey(pod1UID)
+	assert.Assert(t, ok)
+	assert.Equal(t, owner.GetApplicationID(), appID1)
+
+	_, ok = context.GetApplicationByAllocationKey("unknown-allocation")
+	assert.Assert(t, !ok)
+}
+
+func TestBindingDecisionEvent(t *testing.T) {
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.EmitBindingDecisionEvents = true
+	})
+	recorder := setTestRecorder(t)
+
+	context := initContextForTest()
+	context.SetNodeScorer(func(_ *Task, nodeID string) int64 {
+		if nodeID == Host1 {
+			return 77
+		}
+		return 0
+	})
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+	task := NewTask(pod1UID, app, context, newPodHelper(pod1Name, "default", pod1UID, "", appID1, v1.PodPending))
+	app.addTask(task)
+	task.sm.SetState(TaskStates().Allocated)
+	task.nodeName = Host1
+	assert.NilError(t, task.handle(NewBindTaskEvent(appID1, pod1UID)))
+
+	err := utils.WaitForCondition(func() bool {
+		for len(recorder.Events) > 0 {
+			event := <-recorder.Events
+			if strings.Contains(event, "BindingDecision") && strings.Contains(event, "Pod bound to node "+Host1+" with score 77") {
+				return true
+			}
+		}
+		return false
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err, "binding decision event not found")
+}
+
+func TestGetApplicationsOnNode(t *testing.T) {
+	context := initContextForTest()
+	for _, appID := range []string{appID1, appID2, appID3} {
+		app := NewApplication(appID, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+		context.addApplicationToContext(app)
+	}
+	bind := func(appID, taskID, nodeID string) {
+		app := context.GetApplication(appID)
+		task := NewTask(taskID, app, context, newPodHelper("pod-"+taskID, "default", taskID, nodeID, appID, v1.PodRunning))
+		app.addTask(task)
+		task.MarkPreviouslyAllocated(taskID, nodeID)
+	}
+	// two tasks of app1 and one of app2 on Host1, app3 only on Host2
+	bind(appID1, "task01", Host1)
+	bind(appID1, "task02", Host1)
+	bind(appID2, "task03", Host1)
+	bind(appID3, "task04", Host2)
+
+	appIDs := make([]string, 0)
+	for _, app := range context.GetApplicationsOnNode(Host1) {
+		appIDs = append(appIDs, app.GetApplicationID())
+	}
+	sort.Strings(appIDs)
+	assert.DeepEqual(t, appIDs, []string{appID1, appID2})
+	assert.Equal(t, len(context.GetApplicationsOnNode("unknown-node")), 0)
+}
+
+func TestUnparseableNodeResourceEvent(t *testing.T) {
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.WarnOnUnparseableNodeResource = true
+	})
+	recorder := setTestRecorder(t)
+
+	context, apiProvider := initContextAndAPIProviderForTest()
+	dispatcher.Start()
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+	var registered *si.NodeInfo
+	apiProvider.MockSchedulerAPIUpdateNodeFn(func(request *si.NodeRequest) error {
+		for _, node := range request.Nodes {
+			if node.Action == si.NodeInfo_CREATE_DRAIN {
+				registered = node
+				dispatcher.Dispatch(CachedSchedulerNodeEvent{
+					NodeID: node.NodeID,
+					Event:  NodeAccepted,
+				})
+			}
+		}
+		return nil
+	})
+	node := nodeForTest(Host1, "10G", "10")
+	node.Status.Allocatable["example.com/odd"] = resource.MustParse("1.5")
+	context.updateNode(nil, node)
+
+	found := false
+	for len(recorder.Events) > 0 {
+		event := <-recorder.Events
+		if strings.Contains(event, "UnparseableNodeResource") && strings.Contains(event, "example.com/odd") {
+			found = true
+		}
+	}
+	assert.Assert(t, found, "unparseable resource event not found")
+	// the parseable resources are still registered
+	assert.Assert(t, registered != nil, "node was not registered")
+	assert.Equal(t, registered.SchedulableResource.Resources[siCommon.Memory].GetValue(), int64(10*1000*1000*1000))
+	assert.Equal(t, registered.SchedulableResource.Resources[siCommon.CPU].GetValue(), int64(10000))
+}
+
+func TestGetTaskSchedulingCycleCount(t *testing.T) {
+	context := initContextForTest()
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+	app.sm.SetState(ApplicationStates().Running)
+	pod := newPodHelper(pod1Name, "default", pod1UID, "", appID1, v1.PodPending)
+	task := NewTask(pod1UID, app, context, pod)
+	app.addTask(task)
+
+	// every cycle counts while the core has no capacity for the task
+	for i := 1; i <= 3; i++ {
+		app.Schedule()
+		assert.Equal(t, task.GetTaskState(), TaskStates().Pending)
+		context.HandleContainerStateUpdate(&si.UpdateContainerSchedulingStateRequest{
+			ApplicationID: appID1,
+			AllocationKey: pod1UID,
+			State:         si.UpdateContainerSchedulingStateRequest_FAILED,
+			Reason:        "insufficient resources",
+		})
+		assert.Equal(t, context.GetTaskSchedulingCycleCount(appID1, pod1UID), i)
+	}
+
+	// capacity appears and the task is bound: it is no longer considered
+	task.sm.SetState(TaskStates().Bound)
+	app.Schedule()
+	assert.Equal(t, context.GetTaskSchedulingCycleCount(appID1, pod1UID), 3)
+	assert.Equal(t, context.GetTaskSchedulingCycleCount(appID1, "unknown"), 0)
+}
+
+func TestGetQueues(t *testing.T) {
+	context := initContextForTest()
+	assert.DeepEqual(t, context.GetQueues(), []string{})
+	apps := map[string]string{
+		"app-1": "root.c",
+		"app-2": "root.a",
+		"app-3": "root.b",
+		"app-4": "root.a",
+	}
+	for appID, queue := range apps {
+		context.AddApplication(&AddApplicationRequest{
+			Metadata: ApplicationMetadata{
+				ApplicationID: appID,
+				QueueName:     queue,
+				User:          "testuser",
+			},
+		})
+	}
+	assert.DeepEqual(t, context.GetQueues(), []string{"root.a", "root.b", "root.c"})
+}
+
+func TestNotifyTaskCompleteReleaseRetry(t *testing.T) {
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.TaskReleaseMaxRetries = 3
+	})
+	defer func(backoff time.Duration) { releaseRetryBackoff = backoff }(releaseRetryBackoff)
+	releaseRetryBackoff = time.Millisecond
+
+	const pod2UID = "task00002"
+	context, apiProvider := initContextAndAPIProviderForTest()
+	var lock locking.Mutex
+	attempts := make(map[string]int)
+	released := make(map[string]bool)
+	apiProvider.MockSchedulerAPIUpdateAllocationFn(func(request *si.AllocationRequest) error {
+		if request.Releases == nil {
+			return nil
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		key := request.Releases.AllocationsToRelease[0].AllocationKey
+		attempts[key]++
+		switch {
+		case key == pod2UID:
+			return fmt.Errorf("unknown allocation")
+		case attempts[key] == 1:
+			// the first release request fails with a transient error
+			return apierrors.NewTimeoutError("scheduler busy", 1)
+		}
+		released[key] = true
+		return nil
+	})
+	dispatcher.Start()
+	dispatcher.RegisterEventHandler("TestAppHandler", dispatcher.EventTypeApp, context.ApplicationEventHandler())
+	dispatcher.RegisterEventHandler("TestTaskHandler", dispatcher.EventTypeTask, context.TaskEventHandler())
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, apiProvider.GetAPIs().SchedulerAPI)
+	context.addApplicationToContext(app)
+	app.sm.SetState(ApplicationStates().Running)
+	task := NewTask(pod1UID, app, context, newPodHelper(pod1Name, "default", pod1UID, Host1, appID1, v1.PodRunning))
+	app.addTask(task)
+	task.MarkPreviouslyAllocated(pod1UID, Host1)
+
+	// the completion is dispatched again until the release is sent
+	context.NotifyTaskComplete(appID1, pod1UID)
+	err := utils.WaitForCondition(func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return task.GetTaskState() == TaskStates().Completed && released[pod1UID] && !task.isReleasePending()
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err, "release was not retried")
+	lock.Lock()
+	assert.Equal(t, attempts[pod1UID], 2)
+	lock.Unlock()
+	app.Schedule()
+	_, err = app.GetTask(pod1UID)
+	assert.Assert(t, err != nil, "completed task was not removed after the release")
+
+	// errors that are not transient are not retried
+	task2 := NewTask(pod2UID, app, context, newPodHelper("my-pod-2", "default", pod2UID, Host1, appID1, v1.PodRunning))
+	app.addTask(task2)
+	task2.MarkPreviouslyAllocated(pod2UID, Host1)
+	context.NotifyTaskComplete(appID1, pod2UID)
+	err = utils.WaitForCondition(func() bool {
+		return task2.GetTaskState() == TaskStates().Completed
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err, "task was not completed")
+	lock.Lock()
+	assert.Equal(t, attempts[pod2UID], 1)
+	lock.Unlock()
+	assert.Assert(t, !task2.isReleasePending())
+}
+
+func TestGetApplicationResourceHistory(t *testing.T) {
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.ResourceSampleInterval = 10 * time.Second
+		c.ResourceSampleRetention = 30 * time.Second
+	})
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	now := start
+	setTestClock(t, func() time.Time { return now })
+
+	context := initContextForTest()
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+	app.sm.SetState(ApplicationStates().Running)
+	pod := foreignPod(pod1Name, "1G", "500m")
+	pod.UID = pod1UID
+	task := NewTask(pod1UID, app, context, pod)
+	app.addTask(task)
+	task.MarkPreviouslyAllocated(pod1UID, Host1)
+
+	app.Schedule()
+	// within the interval: no new sample
+	now = start.Add(5 * time.Second)
+	app.Schedule()
+	history := context.GetApplicationResourceHistory(appID1)
+	assert.Equal(t, len(history), 1)
+	assert.Equal(t, history[0].Time, start)
+	assert.Equal(t, history[0].Resource.Resources[siCommon.CPU].GetValue(), int64(500))
+
+	// task released
+	task.sm.SetState(TaskStates().Completed)
+	now = start.Add(10 * time.Second)
+	app.Schedule()
+	history = context.GetApplicationResourceHistory(appID1)
+	assert.Equal(t, len(history), 2)
+	assert.Equal(t, history[1].Resource.Resources[siCommon.CPU].GetValue(), int64(0))
+
+	// samples past the retention are dropped
+	now = start.Add(45 * time.Second)
+	app.Schedule()
+	history = context.GetApplicationResourceHistory(appID1)
+	assert.Equal(t, len(history), 1)
+	assert.Equal(t, history[0].Time, now)
+	assert.Assert(t, context.GetApplicationResourceHistory("non-existing-app") == nil)
+}
+
+func TestAddTaskAntiAffinityUnsatisfiable(t *testing.T) {
+	recorder := setTestRecorder(t)
+	context := initContextForTest()
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.EnforceAntiAffinityLocally = true
+	})
+
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+
+	context.schedulerCache.UpdateNode(nodeForTest(Host1, "8G", "4"))
+	context.schedulerCache.UpdateNode(nodeForTest(Host2, "8G", "4"))
+	existing := foreignPod("existing1", "1G", "500m")
+	existing.Labels = map[string]string{"app": "db"}
+	existing.Spec.NodeName = Host1
+	context.schedulerCache.UpdatePod(existing)
+
+	antiAffinityPod := func(name string) *v1.Pod {
+		pod := foreignPod(name, "1G", "500m")
+		pod.Spec.Affinity = &v1.Affinity{
+			PodAntiAffinity: &v1.PodAntiAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: []v1.PodAffinityTerm{{
+					LabelSelector: &apis.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+					TopologyKey:   v1.LabelHostname,
+				}},
+			},
+		}
+		return pod
+	}
+
+	// a free node is left
+	task := context.AddTask(&AddTaskRequest{
+		Metadata: TaskMetadata{
+			ApplicationID: appID1,
+			TaskID:        "task0001",
+			Pod:           antiAffinityPod("task0001"),
+		},
+	})
+	assert.Equal(t, task.GetTaskState(), TaskStates().New)
+
+	// every node runs a matching pod
+	existing = foreignPod("existing2", "1G", "500m")
+	existing.Labels = map[string]string{"app": "db"}
+	existing.Spec.NodeName = Host2
+	context.schedulerCache.UpdatePod(existing)
+	task = context.AddTask(&AddTaskRequest{
+		Metadata: TaskMetadata{
+			ApplicationID: appID1,
+			TaskID:        "task0002",
+			Pod:           antiAffinityPod("task0002"),
+		},
+	})
+	assert.Equal(t, task.GetTaskState(), TaskStates().Failed)
+	found := false
+	for len(recorder.Events) > 0 {
+		event := <-recorder.Events
+		if strings.Contains(event, "PodAntiAffinityUnsatisfiable") {
+			found = true
+		}
+	}
+	assert.Assert(t, found, "anti-affinity event not found")
+
+	// check disabled
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.EnforceAntiAffinityLocally = false
+	})
+	task = context.AddTask(&AddTaskRequest{
+		Metadata: TaskMetadata{
+			ApplicationID: appID1,
+			TaskID:        "task0003",
+			Pod:           antiAffinityPod("task0003"),
+		},
+	})
+	assert.Equal(t, task.GetTaskState(), TaskStates().New)
+}
+
+func TestGetSchedulerConnectionStatus(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	setTestClock(t, func() time.Time { return now })
+
+	context, apiProvider := initContextAndAPIProviderForTest()
+	status := context.GetSchedulerConnectionStatus()
+	assert.Equal(t, status.State, ConnectionUnknown)
+	assert.Assert(t, status.LastSuccess.IsZero())
+	assert.Equal(t, status.RecentErrors, 0)
+
+	apiProvider.MockSchedulerAPIUpdateAllocationFn(func(request *si.AllocationRequest) error {
+		return nil
+	})
+	assert.NilError(t, context.updateAllocation(&si.AllocationRequest{}))
+	status = context.GetSchedulerConnectionStatus()
+	assert.Equal(t, status.State, ConnectionReachable)
+	assert.Equal(t, status.LastSuccess, now)
+	assert.Equal(t, status.RecentErrors, 0)
+
+	// core fails to respond
+	apiProvider.MockSchedulerAPIUpdateAllocationFn(func(request *si.AllocationRequest) error {
+		return fmt.Errorf("connection refused")
+	})
+	lastSuccess := now
+	now = now.Add(time.Minute)
+	assert.ErrorContains(t, context.updateAllocation(&si.AllocationRequest{}), "connection refused")
+	assert.ErrorContains(t, context.updateAllocation(&si.AllocationRequest{}), "connection refused")
+	status = context.GetSchedulerConnectionStatus()
+	assert.Equal(t, status.State, ConnectionUnreachable)
+	assert.Equal(t, status.LastSuccess, lastSuccess)
+	assert.Equal(t, status.RecentErrors, 2)
+
+	// calls made by an application are tracked
+	apiProvider.MockSchedulerAPIUpdateApplicationFn(func(request *si.ApplicationRequest) error {
+		return fmt.Errorf("connection refused")
+	})
+	app := context.AddApplication(&AddApplicationRequest{
+		Metadata: ApplicationMetadata{
+			ApplicationID: appID1,
+			QueueName:     "root.a",
+			User:          "testuser",
+		},
+	})
+	assert.ErrorContains(t, app.handleSubmitApplicationEvent(), "connection refused")
+	status = context.GetSchedulerConnectionStatus()
+	assert.Equal(t, status.RecentErrors, 3)
+
+	// errors age out of the window
+	now = now.Add(connectionErrorWindow + time.Second)
+	status = context.GetSchedulerConnectionStatus()
+	assert.Equal(t, status.State, ConnectionUnreachable)
+	assert.Equal(t, status.RecentErrors, 0)
+}
+
+func TestRepeatedAllocationRejectionEvent(t *testing.T) {
+	recorder := setTestRecorder(t)
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	setTestClock(t, func() time.Time { return now })
+	context := initContextForTest()
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.AllocationRejectionEventThreshold = 3
+		c.AllocationRejectionEventWindow = time.Minute
+	})
+
+	pod := newPodHelper(pod1Name, "default", pod1UID, "", appID1, v1.PodPending)
+	context.schedulerCache.UpdatePod(pod)
+	callback := NewAsyncRMCallback(context)
+	reject := func() {
+		err := callback.UpdateAllocation(&si.AllocationResponse{
+			Rejected: []*si.RejectedAllocationAsk{{AllocationKey: pod1UID, ApplicationID: appID1}},
+		})
+		assert.NilError(t, err)
+	}
+	countEvents := func() int {
+		count := 0
+		for len(recorder.Events) > 0 {
+			if strings.Contains(<-recorder.Events, "RepeatedAllocationRejection") {
+				count++
+			}
+		}
+		return count
+	}
+
+	// below the threshold
+	reject()
+	reject()
+	assert.Equal(t, countEvents(), 0)
+	// threshold reached: one aggregated event, further rejections in the window are not reported
+	for i := 0; i < 5; i++ {
+		reject()
+	}
+	assert.Equal(t, countEvents(), 1)
+
+	// window expired: counting restarts
+	now = now.Add(2 * time.Minute)
+	reject()
+	reject()
+	assert.Equal(t, countEvents(), 0)
+	reject()
+	assert.Equal(t, countEvents(), 1)
+
+	// disabled
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.AllocationRejectionEventThreshold = 0
+	})
+	now = now.Add(2 * time.Minute)
+	for i := 0; i < 5; i++ {
+		reject()
+	}
+	assert.Equal(t, countEvents(), 0)
+}
+
+func TestGetApplicationTaskGroups(t *testing.T) {
+	context := initContextForTest()
+	assert.Assert(t, context.GetApplicationTaskGroups(appID1) == nil)
+
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+	assert.DeepEqual(t, context.GetApplicationTaskGroups(appID1), []string{})
+
+	for i, group := range []string{"workers", "driver", "workers", ""} {
+		taskID := fmt.Sprintf("task%04d", i)
+		task := NewTask(taskID, app, context, newPodHelper(taskID, "default", taskID, "", appID1, v1.PodPending))
+		task.setTaskGroupName(group)
+		app.addTask(task)
+	}
+	assert.DeepEqual(t, context.GetApplicationTaskGroups(appID1), []string{"driver", "workers"})
+}
+
+func TestUpdatePodFailedRestartNever(t *testing.T) {
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.FailRestartNeverPods = true
+	})
+	recorder := setTestRecorder(t)
+
+	context := initContextForTest()
+	dispatcher.Start()
+	dispatcher.RegisterEventHandler("TestAppHandler", dispatcher.EventTypeApp, context.ApplicationEventHandler())
+	dispatcher.RegisterEventHandler("TestTaskHandler", dispatcher.EventTypeTask, context.TaskEventHandler())
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+
+	context.schedulerCache.UpdateNode(nodeForTest(Host1, "10G", "10"))
+	pod := newPodHelper(pod1Name, "default", pod1UID, Host1, appID1, v1.PodRunning)
+	pod.Spec.RestartPolicy = v1.RestartPolicyNever
+	context.AddPod(pod)
+	app := context.GetApplication(appID1)
+	assert.Assert(t, app != nil)
+	task, err := app.GetTask(pod1UID)
+	assert.NilError(t, err)
+	task.MarkPreviouslyAllocated(pod1UID, Host1)
+
+	failed := pod.DeepCopy()
+	failed.Status.Phase = v1.PodFailed
+	failed.Status.ContainerStatuses = []v1.ContainerStatus{{
+		Name:  "container-1",
+		State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"}},
+	}}
+	context.UpdatePod(pod, failed)
+	err = utils.WaitForCondition(func() bool {
+		return task.GetTaskState() == TaskStates().Failed
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err)
+	found := false
+	for len(recorder.Events) > 0 {
+		event := <-recorder.Events
+		if strings.Contains(event, "PodFailed") && strings.Contains(event, "container-1 terminated with exit code 137: OOMKilled") {
+			found = true
+		}
+	}
+	assert.Assert(t, found, "termination reason event not found")
+}
+
+func TestExportTaskList(t *testing.T) {
+	context := initContextForTest()
+	assert.DeepEqual(t, context.ExportTaskList(), [][]string{taskListHeader})
+
+	app1 := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	app2 := NewApplication(appID2, "root.b", "otheruser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app1)
+	context.addApplicationToContext(app2)
+	task2 := NewTask("task0002", app1, context, foreignPod("task0002", "1G", "500m"))
+	app1.addTask(task2)
+	task1 := NewTask("task0001", app1, context, foreignPod("task0001", "2G", "1"))
+	task1.MarkPreviouslyAllocated("task0001", Host1)
+	app1.addTask(task1)
+	app2.addTask(NewTask("task0003", app2, context, foreignPod("task0003", "1G", "500m")))
+
+	expected := [][]string{
+		taskListHeader,
+		{appID1, "task0001", TaskStates().Bound, Host1, "root.a", "testuser", "memory=2000000000;pods=1;vcore=1000"},
+		{appID1, "task0002", TaskStates().New, "", "root.a", "testuser", "memory=1000000000;pods=1;vcore=500"},
+		{appID2, "task0003", TaskStates().New, "", "root.b", "otheruser", "memory=1000000000;pods=1;vcore=500"},
+	}
+	assert.DeepEqual(t, context.ExportTaskList(), expected)
+
+	var buf strings.Builder
+	assert.NilError(t, context.ExportTaskListCSV(&buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, len(lines), 4)
+	assert.Equal(t, lines[0], "appID,taskID,state,node,queue,user,resource")
+	assert.Equal(t, lines[1], "app00001,task0001,Bound,HOST1,root.a,testuser,memory=2000000000;pods=1;vcore=1000")
+}
+
+func TestAddNodeAdoptsOrphanedPods(t *testing.T) {
+	context, apiProvider := initContextAndAPIProviderForTest()
+	dispatcher.Start()
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+	apiProvider.MockSchedulerAPIUpdateNodeFn(func(request *si.NodeRequest) error {
+		for _, node := range request.Nodes {
+			if node.Action == si.NodeInfo_CREATE_DRAIN {
+				dispatcher.Dispatch(CachedSchedulerNodeEvent{
+					NodeID: node.NodeID,
+					Event:  NodeAccepted,
+				})
+			}
+		}
+		return nil
+	})
+
+	// pod references a node that is not known yet
+	context.AddPod(newPodHelper(pod1Name, "default", pod1UID, Host1, appID1, v1.PodRunning))
+	assert.Assert(t, context.schedulerCache.IsPodOrphaned(pod1UID), "pod should be orphaned")
+	assert.Assert(t, context.getTask(appID1, pod1UID) == nil, "task created for orphaned pod")
+
+	context.addNode(nodeForTest(Host1, "10G", "10"))
+	assert.Assert(t, !context.schedulerCache.IsPodOrphaned(pod1UID), "pod should have been adopted")
+	task := context.getTask(appID1, pod1UID)
+	assert.Assert(t, task != nil, "task not created for adopted pod")
+	assert.Equal(t, task.GetOrigin(), TaskOriginRecovered)
+	assert.Equal(t, task.GetTaskPod().Spec.NodeName, Host1)
+	pod, ok := context.schedulerCache.GetPod(pod1UID)
+	assert.Assert(t, ok, "adopted pod not in cache")
+	assert.Equal(t, pod.Spec.NodeName, Host1)
+}
+
+func TestGetPreemptionVictims(t *testing.T) {
+	context := initContextForTest()
+	assert.Assert(t, context.GetPreemptionVictims(appID1) == nil)
+
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+	for _, taskID := range []string{"task0003", "task0001", "task0002"} {
+		task := NewTask(taskID, app, context, newPodHelper(taskID, "default", taskID, Host1, appID1, v1.PodRunning))
+		task.MarkPreviouslyAllocated(taskID, Host1)
+		app.addTask(task)
+	}
+	assert.Equal(t, len(context.GetPreemptionVictims(appID1)), 0)
+
+	preempted := si.TerminationType_name[int32(si.TerminationType_PREEMPTED_BY_SCHEDULER)]
+	app.handleReleaseAppAllocationEvent("task0003", preempted)
+	app.handleReleaseAppAllocationEvent("task0001", preempted)
+	app.handleReleaseAppAllocationEvent("task0002", si.TerminationType_name[int32(si.TerminationType_TIMEOUT)])
+	victims := context.GetPreemptionVictims(appID1)
+	assert.Equal(t, len(victims), 2)
+	assert.Equal(t, victims[0].GetTaskID(), "task0003")
+	assert.Equal(t, victims[1].GetTaskID(), "task0001")
+
+	// victims are kept after the completed tasks are removed from the application
+	app.SetState(ApplicationStates().Running)
+	for _, taskID := range []string{"task0001", "task0002", "task0003"} {
+		task, err := app.GetTask(taskID)
+		assert.NilError(t, err)
+		task.sm.SetState(TaskStates().Completed)
+	}
+	app.Schedule()
+	assert.Equal(t, len(app.getTasks(TaskStates().Completed)), 0)
+	victims = context.GetPreemptionVictims(appID1)
+	assert.Equal(t, len(victims), 2)
+	assert.Equal(t, victims[0].GetTaskID(), "task0003")
+	assert.Equal(t, victims[1].GetTaskID(), "task0001")
+
+	// the list of victims is bounded, the oldest victims are dropped
+	for i := 0; i < maxPreemptionVictims; i++ {
+		taskID := fmt.Sprintf("task1%03d", i)
+		task := NewTask(taskID, app, context, newPodHelper(taskID, "default", taskID, Host1, appID1, v1.PodRunning))
+		task.MarkPreviouslyAllocated(taskID, Host1)
+		app.addTask(task)
+		app.handleReleaseAppAllocationEvent(taskID, preempted)
+	}
+	victims = context.GetPreemptionVictims(appID1)
+	assert.Equal(t, len(victims), maxPreemptionVictims)
+	assert.Equal(t, victims[0].GetTaskID(), "task1000")
+}
+
+func TestPeriodicStateDump(t *testing.T) {
+	dir := t.TempDir()
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.PeriodicStateDumpInterval = 10 * time.Millisecond
+		c.PeriodicStateDumpPath = dir
+		c.PeriodicStateDumpFiles = 2
+	})
+	listDumps := func() []string {
+		entries, err := os.ReadDir(dir)
+		assert.NilError(t, err)
+		names := make([]string, 0)
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		return names
+	}
+	context := initContextForTest()
+	context.schedulerCache.UpdateNode(nodeForTest(Host1, "10G", "10"))
+
+	// the oldest files are removed once the number of files to keep is reached
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	setTestClock(t, func() time.Time { return now })
+	for i := 0; i < 3; i++ {
+		assert.NilError(t, context.writeStateDump())
+		now = now.Add(time.Minute)
+	}
+	setTestClock(t, time.Now)
+	assert.DeepEqual(t, listDumps(), []string{
+		"yunikorn-state-20240101T100100.000000000.json",
+		"yunikorn-state-20240101T100200.000000000.json",
+	})
+	dump, err := os.ReadFile(filepath.Join(dir, listDumps()[0]))
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(string(dump), Host1), "node missing from state dump")
+
+	// periodic dumps replace the old files
+	stopChan := make(chan struct{})
+	done := context.RunPeriodicStateDump(stopChan)
+	err = utils.WaitForCondition(func() bool {
+		dumps := listDumps()
+		return len(dumps) == 2 && !strings.HasPrefix(dumps[0], "yunikorn-state-2024")
+	}, 10*time.Millisecond, 2*time.Second)
+	close(stopChan)
+	<-done
+	assert.NilError(t, err)
+
+	// not configured: nothing is started
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.PeriodicStateDumpInterval = 0
+	})
+	select {
+	case <-context.RunPeriodicStateDump(make(chan struct{})):
+	default:
+		t.Fatal("periodic state dump started without an interval")
+	}
+}
+
+func TestGetTaskWaitReason(t *testing.T) {
+	context := initContextForTest()
+	assert.Equal(t, context.GetTaskWaitReason(appID1, "task0001"), "")
+
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+	app.addTask(NewTask("task0001", app, context, foreignPod("task0001", "1G", "500m")))
+	assert.Equal(t, context.GetTaskWaitReason(appID1, "task0001"), "")
+
+	reason := "Request 'task0001' does not fit in queue 'root.a' (insufficient memory)"
+	context.PublishEvents([]*si.EventRecord{{
+		Type:            si.EventRecord_REQUEST,
+		EventChangeType: si.EventRecord_NONE,
+		ObjectID:        "task0001",
+		ReferenceID:     appID1,
+		Message:         reason,
+	}})
+	assert.Equal(t, context.GetTaskWaitReason(appID1, "task0001"), reason)
+
+	// lifecycle events do not replace the reason
+	context.PublishEvents([]*si.EventRecord{{
+		Type:              si.EventRecord_REQUEST,
+		EventChangeType:   si.EventRecord_ADD,
+		EventChangeDetail: si.EventRecord_DETAILS_NONE,
+		ObjectID:          "task0001",
+		ReferenceID:       appID1,
+		Message:           "request added",
+	}})
+	assert.Equal(t, context.GetTaskWaitReason(appID1, "task0001"), reason)
+}
+
+func TestUpdateNodeReplacedUID(t *testing.T) {
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.ResetReplacedNodes = true
+	})
+
+	context, apiProvider := initContextAndAPIProviderForTest()
+	dispatcher.RegisterEventHandler("TestTaskHandler", dispatcher.EventTypeTask, context.TaskEventHandler())
+	dispatcher.Start()
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+	var decommissioned atomic.Int32
+	apiProvider.MockSchedulerAPIUpdateNodeFn(func(request *si.NodeRequest) error {
+		for _, node := range request.Nodes {
+			switch node.Action {
+			case si.NodeInfo_CREATE_DRAIN:
+				dispatcher.Dispatch(CachedSchedulerNodeEvent{
+					NodeID: node.NodeID,
+					Event:  NodeAccepted,
+				})
+			case si.NodeInfo_DECOMISSION:
+				decommissioned.Add(1)
+			}
+		}
+		return nil
+	})
+
+	context.addNode(nodeForTest(Host1, "10G", "10"))
+	pod := foreignPod("foreign-1", "1G", "500m")
+	pod.Spec.NodeName = Host1
+	context.AddPod(pod)
+	ykPod := newPodHelper(pod1Name, "default", pod1UID, Host1, appID1, v1.PodRunning)
+	context.AddPod(ykPod)
+	task, err := context.GetApplication(appID1).GetTask(pod1UID)
+	assert.NilError(t, err)
+	task.sm.SetState(TaskStates().Bound)
+	_, occupied, ok := context.schedulerCache.SnapshotResources(Host1)
+	assert.Assert(t, ok)
+	assert.Equal(t, occupied.Resources[siCommon.Memory].GetValue(), int64(1000*1000*1000))
+
+	// same UID: plain update
+	context.updateNode(nil, nodeForTest(Host1, "10G", "10"))
+	assert.Equal(t, decommissioned.Load(), int32(0))
+	assert.Equal(t, len(context.schedulerCache.GetNode(Host1).Pods), 2)
+	assert.Equal(t, task.GetTaskState(), TaskStates().Bound)
+
+	// node recreated with a new UID
+	replaced := nodeForTest(Host1, "10G", "10")
+	replaced.UID = "uid_0002"
+	context.updateNode(nil, replaced)
+	assert.Equal(t, decommissioned.Load(), int32(1))
+	nodeInfo := context.schedulerCache.GetNode(Host1)
+	assert.Assert(t, nodeInfo != nil, "replacement node not in cache")
+	assert.Equal(t, nodeInfo.Node().UID, types.UID("uid_0002"))
+	assert.Equal(t, len(nodeInfo.Pods), 0)
+	_, occupied, ok = context.schedulerCache.SnapshotResources(Host1)
+	assert.Assert(t, ok)
+	assert.Equal(t, occupied.Resources[siCommon.Memory].GetValue(), int64(0))
+	_, ok = context.schedulerCache.GetPod("foreign-1")
+	assert.Assert(t, !ok, "pod of the replaced node still in cache")
+	_, ok = context.schedulerCache.GetPod(pod1UID)
+	assert.Assert(t, !ok, "YuniKorn pod of the replaced node still in cache")
+	// the task of the YuniKorn pod on the replaced node is released
+	err = utils.WaitForCondition(func() bool {
+		return task.GetTaskState() == TaskStates().Completed
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err, "task of the replaced node was not completed")
+}
+
+func TestGetApplicationsCreatedBetween(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	now := start
+	setTestClock(t, func() time.Time { return now })
+
+	context := initContextForTest()
+	for i, appID := range []string{appID1, appID2, appID3} {
+		now = start.Add(time.Duration(i) * time.Minute)
+		context.addApplicationToContext(NewApplication(appID, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI()))
+	}
+	appIDs := func(apps []*Application) []string {
+		ids := make([]string, 0, len(apps))
+		for _, app := range apps {
+			ids = append(ids, app.GetApplicationID())
+		}
+		return ids
+	}
+
+	assert.DeepEqual(t, appIDs(context.GetApplicationsCreatedBetween(start, start.Add(time.Hour))), []string{appID1, appID2, appID3})
+	// start is inclusive, end is exclusive
+	assert.DeepEqual(t, appIDs(context.GetApplicationsCreatedBetween(start.Add(time.Minute), start.Add(2*time.Minute))), []string{appID2})
+	assert.DeepEqual(t, appIDs(context.GetApplicationsCreatedBetween(start.Add(-time.Hour), start)), []string{})
+}
+
+func TestAddPodSecondarySchedulerNameQueue(t *testing.T) {
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.SchedulerNameQueueMap = map[string]string{"yunikorn-batch": "root.batch"}
+	})
+
+	context := initContextForTest()
+	pod := newPodHelper(pod1Name, "default", pod1UID, "", appID1, v1.PodPending)
+	pod.Spec.SchedulerName = "yunikorn-batch"
+	context.AddPod(pod)
+	app := context.GetApplication(appID1)
+	assert.Assert(t, app != nil, "pod with secondary scheduler name not managed")
+	assert.Equal(t, app.GetQueue(), "root.batch")
+
+	// an explicit queue wins over the mapped default
+	pod = newPodHelper("pod2", "default", "uid2", "", appID2, v1.PodPending)
+	pod.Spec.SchedulerName = "yunikorn-batch"
+	pod.Labels[constants.LabelQueueName] = "root.explicit"
+	context.AddPod(pod)
+	app = context.GetApplication(appID2)
+	assert.Assert(t, app != nil)
+	assert.Equal(t, app.GetQueue(), "root.explicit")
+
+	// unknown scheduler names are not managed
+	pod = newPodHelper("pod3", "default", "uid3", "", appID3, v1.PodPending)
+	pod.Spec.SchedulerName = "other-scheduler"
+	context.AddPod(pod)
+	assert.Assert(t, context.GetApplication(appID3) == nil)
+}
+
+func TestGetNodeAvailablePodSlots(t *testing.T) {
+	context := initContextForTest()
+	_, ok := context.GetNodeAvailablePodSlots(Host1)
+	assert.Assert(t, !ok, "slots returned for unknown node")
+	context.schedulerCache.UpdateNode(nodeForTest(Host1, "10G", "10"))
+	context.schedulerCache.UpdateNode(nodeForTest(Host2, "10G", "10"))
+	_, ok = context.GetNodeAvailablePodSlots(Host1)
+	assert.Assert(t, !ok, "slots returned without pods to base the average on")
+
+	// average pod: 2G memory and 1 cpu
+	for i, memory := range []string{"1G", "3G"} {
+		pod := foreignPod(fmt.Sprintf("foreign-%d", i), memory, "1")
+		pod.Spec.NodeName = Host1
+		context.AddPod(pod)
+	}
+	// node 1: 6G memory and 8 cpu left, memory limits
+	slots, ok := context.GetNodeAvailablePodSlots(Host1)
+	assert.Assert(t, ok)
+	assert.Equal(t, slots, 3)
+	// node 2: empty
+	slots, ok = context.GetNodeAvailablePodSlots(Host2)
+	assert.Assert(t, ok)
+	assert.Equal(t, slots, 5)
+}
+
+func TestQuarantineFlappingNode(t *testing.T) {
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.NodeFlapThreshold = 3
+		c.NodeFlapWindow = time.Minute
+	})
+	recorder := setTestRecorder(t)
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	setTestClock(t, func() time.Time { return now })
+
+	context, apiProvider := initContextAndAPIProviderForTest()
+	dispatcher.Start()
+	defer dispatcher.UnregisterAllEventHandlers()
+	defer dispatcher.Stop()
+	var drained atomic.Int32
+	apiProvider.MockSchedulerAPIUpdateNodeFn(func(request *si.NodeRequest) error {
+		for _, node := range request.Nodes {
+			switch node.Action {
+			case si.NodeInfo_CREATE_DRAIN:
+				dispatcher.Dispatch(CachedSchedulerNodeEvent{
+					NodeID: node.NodeID,
+					Event:  NodeAccepted,
+				})
+			case si.NodeInfo_DRAIN_NODE:
+				drained.Add(1)
+			}
+		}
+		return nil
+	})
+	withReadiness := func(ready bool) *v1.Node {
+		node := nodeForTest(Host1, "10G", "10")
+		status := v1.ConditionFalse
+		if ready {
+			status = v1.ConditionTrue
+		}
+		node.Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: status}}
+		return node
+	}
+	context.addNode(withReadiness(true))
+
+	// flips spread out over more than the window are not counted together
+	context.updateNode(nil, withReadiness(false))
+	now = now.Add(2 * time.Minute)
+	context.updateNode(nil, withReadiness(true))
+	now = now.Add(10 * time.Second)
+	context.updateNode(nil, withReadiness(true))
+	assert.Equal(t, drained.Load(), int32(0))
+	assert.Equal(t, len(context.ListDrainingNodes()), 0)
+
+	// rapid flips reach the threshold
+	now = now.Add(10 * time.Second)
+	context.updateNode(nil, withReadiness(false))
+	now = now.Add(10 * time.Second)
+	context.updateNode(nil, withReadiness(true))
+	assert.Equal(t, drained.Load(), int32(1))
+	assert.DeepEqual(t, context.ListDrainingNodes(), []string{Host1})
+	found := false
+	for len(recorder.Events) > 0 {
+		if strings.Contains(<-recorder.Events, "NodeQuarantined") {
+			found = true
+		}
+	}
+	assert.Assert(t, found, "quarantine event not found")
+
+	// an already quarantined node is not drained again
+	for i := 0; i < 4; i++ {
+		now = now.Add(time.Second)
+		context.updateNode(nil, withReadiness(i%2 == 1))
+	}
+	assert.Equal(t, drained.Load(), int32(1))
+}
+
+func TestGetApplicationFirstScheduleLatency(t *testing.T) {
+	submitted := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	now := submitted
+	setTestClock(t, func() time.Time { return now })
+
+	context := initContextForTest()
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+	app.sm.SetState(ApplicationStates().Running)
+	tasks := make([]*Task, 0)
+	for _, taskID := range []string{"task0001", "task0002"} {
+		task := NewTask(taskID, app, context, newPodHelper(taskID, "default", taskID, "", appID1, v1.PodPending))
+		app.addTask(task)
+		task.sm.SetState(TaskStates().Allocated)
+		tasks = append(tasks, task)
+	}
+	_, ok := context.GetApplicationFirstScheduleLatency(appID1)
+	assert.Assert(t, !ok, "latency reported before any bind")
+
+	now = submitted.Add(30 * time.Second)
+	assert.NilError(t, tasks[0].handle(NewBindTaskEvent(appID1, "task0001")))
+	// later binds do not change the latency
+	now = submitted.Add(time.Minute)
+	assert.NilError(t, tasks[1].handle(NewBindTaskEvent(appID1, "task0002")))
+	latency, ok := context.GetApplicationFirstScheduleLatency(appID1)
+	assert.Assert(t, ok)
+	assert.Equal(t, latency, 30*time.Second)
+
+	_, ok = context.GetApplicationFirstScheduleLatency(appID2)
+	assert.Assert(t, !ok)
+}
+
+func TestAddTaskDropZeroResourceRequests(t *testing.T) {
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.DropZeroResourceRequests = true
+	})
+
+	context := initContextForTest()
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+	zeroGPUPod := func(name string) *v1.Pod {
+		pod := foreignPod(name, "1G", "500m")
+		pod.Spec.Containers[0].Resources.Requests["nvidia.com/gpu"] = resource.MustParse("0")
+		return pod
+	}
+
+	task := context.AddTask(&AddTaskRequest{
+		Metadata: TaskMetadata{
+			ApplicationID: appID1,
+			TaskID:        "task0001",
+			Pod:           zeroGPUPod("task0001"),
+		},
+	})
+	res := task.getResource()
+	_, ok := res.Resources["nvidia.com/gpu"]
+	assert.Assert(t, !ok, "zero gpu request forwarded")
+	assert.Equal(t, res.Resources[siCommon.Memory].GetValue(), int64(1000*1000*1000))
+	ask := common.CreateAllocationForTask(appID1, "task0001", "", res, false, "", task.GetTaskPod(), false, nil)
+	_, ok = ask.Allocations[0].ResourcePerAlloc.Resources["nvidia.com/gpu"]
+	assert.Assert(t, !ok, "zero gpu request in the allocation")
+
+	// disabled: the zero entry is kept
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.DropZeroResourceRequests = false
+	})
+	task = context.AddTask(&AddTaskRequest{
+		Metadata: TaskMetadata{
+			ApplicationID: appID1,
+			TaskID:        "task0002",
+			Pod:           zeroGPUPod("task0002"),
+		},
+	})
+	_, ok = task.getResource().Resources["nvidia.com/gpu"]
+	assert.Assert(t, ok)
+}
+
+func TestGetCachedPodsForApp(t *testing.T) {
+	context := initContextForTest()
+	context.AddPod(newPodHelper("pod1", "default", "uid1", "", appID1, v1.PodPending))
+	context.AddPod(newPodHelper("pod2", "default", "uid2", "", appID2, v1.PodPending))
+	context.AddPod(newPodHelper("pod3", "default", "uid3", "", appID1, v1.PodPending))
+	context.AddPod(foreignPod("foreign", "1G", "500m"))
+
+	pods := context.GetCachedPodsForApp(appID1)
+	assert.Equal(t, len(pods), 2)
+	assert.Equal(t, pods[0].Name, "pod1")
+	assert.Equal(t, pods[1].Name, "pod3")
+	pods = context.GetCachedPodsForApp(appID2)
+	assert.Equal(t, len(pods), 1)
+	assert.Equal(t, pods[0].Name, "pod2")
+	assert.Equal(t, len(context.GetCachedPodsForApp(appID3)), 0)
+
+	// the returned pods are copies
+	pods[0].Name = "changed"
+	cached, ok := context.schedulerCache.GetPod("uid2")
+	assert.Assert(t, ok)
+	assert.Equal(t, cached.Name, "pod2")
+}
+
+func TestAddPodWorkloadKindTag(t *testing.T) {
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.TagWorkloadKind = true
+	})
+	controller := true
+
+	context := initContextForTest()
+	pod := newPodHelper(pod1Name, "default", pod1UID, "", appID1, v1.PodPending)
+	pod.OwnerReferences = []apis.OwnerReference{{APIVersion: "batch/v1", Kind: "Job", Name: "job-1", UID: "job-uid", Controller: &controller}}
+	context.AddPod(pod)
+	app := context.GetApplication(appID1)
+	assert.Assert(t, app != nil)
+	assert.Equal(t, app.GetTags()[constants.AppTagWorkloadKind], "Job")
+
+	// jobs created by a cron job report the cron job
+	job := &batchv1.Job{
+		ObjectMeta: apis.ObjectMeta{
+			Name:            "cron-1-28391",
+			Namespace:       "default",
+			UID:             "cron-job-uid",
+			OwnerReferences: []apis.OwnerReference{{APIVersion: "batch/v1", Kind: "CronJob", Name: "cron-1", UID: "cron-uid", Controller: &controller}},
+		},
+	}
+	assert.NilError(t, context.apiProvider.GetAPIs().JobInformer.Informer().GetIndexer().Add(job))
+	pod = newPodHelper("pod4", "default", "uid4", "", "app00004", v1.PodPending)
+	pod.OwnerReferences = []apis.OwnerReference{{APIVersion: "batch/v1", Kind: "Job", Name: job.Name, UID: job.UID, Controller: &controller}}
+	context.AddPod(pod)
+	app = context.GetApplication("app00004")
+	assert.Assert(t, app != nil)
+	assert.Equal(t, app.GetTags()[constants.AppTagWorkloadKind], "CronJob")
+
+	// replica sets of a deployment report the deployment
+	pod = newPodHelper("pod2", "default", "uid2", "", appID2, v1.PodPending)
+	pod.Labels["pod-template-hash"] = "5d8f7c"
+	pod.OwnerReferences = []apis.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-5d8f7c", UID: "rs-uid", Controller: &controller}}
+	context.AddPod(pod)
+	app = context.GetApplication(appID2)
+	assert.Assert(t, app != nil)
+	assert.Equal(t, app.GetTags()[constants.AppTagWorkloadKind], "Deployment")
+
+	// pods without a controller are not tagged
+	context.AddPod(newPodHelper("pod3", "default", "uid3", "", appID3, v1.PodPending))
+	app = context.GetApplication(appID3)
+	assert.Assert(t, app != nil)
+	_, ok := app.GetTags()[constants.AppTagWorkloadKind]
+	assert.Assert(t, !ok)
+}
+
+func TestGetTasksPendingLongerThan(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	setTestClock(t, func() time.Time { return start })
+
+	context := initContextForTest()
+	app1 := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	app2 := NewApplication(appID2, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	app3 := NewApplication(appID3, "root.b", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app1)
+	context.addApplicationToContext(app2)
+	context.addApplicationToContext(app3)
+	addTask := func(app *Application, taskID string, age time.Duration, state string) *Task {
+		pod := newPodHelper(taskID, "default", taskID, "", app.GetApplicationID(), v1.PodPending)
+		pod.CreationTimestamp = apis.NewTime(start.Add(-age))
+		task := NewTask(taskID, app, context, pod)
+		task.sm.SetState(state)
+		app.addTask(task)
+		return task
+	}
+	old1 := addTask(app1, "task0001", 20*time.Minute, TaskStates().Pending)
+	addTask(app1, "task0002", time.Minute, TaskStates().Pending)
+	addTask(app1, "task0003", time.Hour, TaskStates().Bound)
+	old2 := addTask(app2, "task0004", 30*time.Minute, TaskStates().Pending)
+	addTask(app3, "task0005", time.Hour, TaskStates().Pending)
+
+	tasks := context.GetTasksPendingLongerThan("root.a", 10*time.Minute)
+	assert.Equal(t, len(tasks), 2)
+	assert.Equal(t, tasks[0], old2)
+	assert.Equal(t, tasks[1], old1)
+
+	assert.Equal(t, len(context.GetTasksPendingLongerThan("root.a", time.Hour)), 0)
+	assert.Equal(t, len(context.GetTasksPendingLongerThan("root.b", 10*time.Minute)), 1)
+	assert.Equal(t, len(context.GetTasksPendingLongerThan("root.c", 0)), 0)
+}
+
+func TestUpdatePriorityClassRepropagatesPreemptionPolicy(t *testing.T) {
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.RepropagatePCChanges = true
+	})
+
+	context, apiProvider := initContextAndAPIProviderForTest()
+	allocations := make([]*si.Allocation, 0)
+	apiProvider.MockSchedulerAPIUpdateAllocationFn(func(request *si.AllocationRequest) error {
+		allocations = append(allocations, request.Allocations...)
+		return nil
+	})
+	pc := &schedulingv1.PriorityClass{
+		ObjectMeta: apis.ObjectMeta{Name: "pc-test"},
+		Value:      100,
+	}
+	context.addPriorityClass(pc)
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, apiProvider.GetAPIs().SchedulerAPI)
+	context.addApplicationToContext(app)
+	addTask := func(taskID, pcName string, bound bool) {
+		pod := newPodHelper(taskID, "default", taskID, "", appID1, v1.PodPending)
+		pod.Spec.PriorityClassName = pcName
+		task := NewTask(taskID, app, context, pod)
+		app.addTask(task)
+		if bound {
+			task.MarkPreviouslyAllocated(taskID, fakeNodeName)
+		}
+	}
+	addTask("task0001", "pc-test", true)
+	addTask("task0002", "pc-test", false)
+	addTask("task0003", "other", true)
+
+	// value only change: nothing is sent
+	pc2 := pc.DeepCopy()
+	pc2.Value = 200
+	context.updatePriorityClass(pc, pc2)
+	assert.Equal(t, len(allocations), 0)
+
+	// opting out of preemption updates the bound task of the priority class
+	pc3 := pc2.DeepCopy()
+	pc3.Annotations = map[string]string{constants.AnnotationAllowPreemption: constants.False}
+	context.updatePriorityClass(pc2, pc3)
+	assert.Equal(t, len(allocations), 1)
+	assert.Equal(t, allocations[0].AllocationKey, "task0001")
+	assert.Equal(t, allocations[0].NodeID, fakeNodeName)
+	assert.Equal(t, allocations[0].PreemptionPolicy.AllowPreemptSelf, false)
+
+	// disabled: the policy change is not propagated
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.RepropagatePCChanges = false
+	})
+	allocations = allocations[:0]
+	context.updatePriorityClass(pc3, pc2)
+	assert.Equal(t, len(allocations), 0)
+}
+
+func TestGetApplicationHealthReport(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	setTestClock(t, func() time.Time { return start })
+
+	context, apiProvider := initContextAndAPIProviderForTest()
+	mgr := NewPlaceholderManager(apiProvider.GetAPIs())
+	mgr.Start()
+	defer mgr.Stop()
+	_, err := context.GetApplicationHealthReport(appID1)
+	assert.ErrorContains(t, err, "not found")
+
+	app := NewApplication(appID1, "root.a", "testuser", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+	app.sm.SetState(ApplicationStates().Running)
+	addTask := func(taskID string, age time.Duration, state string) *Task {
+		pod := newPodHelper(taskID, "default", taskID, "", appID1, v1.PodPending)
+		pod.CreationTimestamp = apis.NewTime(start.Add(-age))
+		task := NewTask(taskID, app, context, pod)
+		task.sm.SetState(state)
+		app.addTask(task)
+		return task
+	}
+	addTask("task0001", time.Hour, TaskStates().Pending).setWaitReason("insufficient resources in queue")
+	addTask("task0002", time.Minute, TaskStates().Pending)
+	addTask("task0003", time.Hour, TaskStates().Pending)
+	addTask("task0004", time.Hour, TaskStates().Bound)
+
+	setTestClock(t, func() time.Time { return start.Add(5 * time.Minute) })
+	report, err := context.GetApplicationHealthReport(appID1)
+	assert.NilError(t, err)
+	assert.Equal(t, report.State, ApplicationStates().Running)
+	assert.DeepEqual(t, report.TaskStates, map[string]int{TaskStates().Pending: 3, TaskStates().Bound: 1})
+	assert.Equal(t, report.PendingDuration, 5*time.Minute)
+	assert.DeepEqual(t, report.StuckTasks, []string{"task0001", "task0003"})
+	assert.Equal(t, report.FailureReason, "")
+	assert.DeepEqual(t, report.WaitReasons, map[string]string{"task0001": "insufficient resources in queue"})
+
+	// a bound task stops the pending duration, a failure is reported
+	app.recordFirstBind()
+	err = app.handle(NewFailApplicationEvent(appID1, constants.ApplicationInsufficientResourcesFailure))
+	assert.NilError(t, err)
+	setTestClock(t, func() time.Time { return start.Add(time.Hour) })
+	report, err = context.GetApplicationHealthReport(appID1)
+	assert.NilError(t, err)
+	assert.Equal(t, report.State, ApplicationStates().Failing)
+	assert.Equal(t, report.PendingDuration, 5*time.Minute)
+	assert.Equal(t, report.FailureReason, constants.ApplicationInsufficientResourcesFailure)
+}
@@ -20,6 +20,7 @@ package external
 
 import (
 	"fmt"
+	"sort"
 	"sync/atomic"
 
 	"go.uber.org/zap"
@@ -61,6 +62,7 @@ type SchedulerCache struct {
 	nodesMap              map[string]*framework.NodeInfo // node name to NodeInfo map
 	nodeCapacity          map[string]*si.Resource        // node name to node resource capacity
 	nodeOccupied          map[string]*si.Resource        // node name to node occupied resources
+	drainingNodes         map[string]bool                // set of node names which are draining in the core
 	podsMap               map[string]*v1.Pod
 	pcMap                 map[string]*schedulingv1.PriorityClass
 	assignedPods          map[string]string      // map of pods to the node they are currently assigned to
@@ -92,6 +94,7 @@ func NewSchedulerCache(clients *client.Clients) *SchedulerCache {
 		nodesMap:              make(map[string]*framework.NodeInfo),
 		nodeCapacity:          make(map[string]*si.Resource),
 		nodeOccupied:          make(map[string]*si.Resource),
+		drainingNodes:         make(map[string]bool),
 		podsMap:               make(map[string]*v1.Pod),
 		pcMap:                 make(map[string]*schedulingv1.PriorityClass),
 		assignedPods:          make(map[string]string),
@@ -179,6 +182,44 @@ func (cache *SchedulerCache) GetNode(name string) *framework.NodeInfo {
 	return nil
 }
 
+// GetNodeNames returns the sorted names of all nodes in the cache
+func (cache *SchedulerCache) GetNodeNames() []string {
+	cache.lock.RLock()
+	defer cache.lock.RUnlock()
+	names := make([]string, 0, len(cache.nodesMap))
+	for name := range cache.nodesMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetNodeDraining marks a node in the cache as draining or no longer draining.
+// Nodes that are not in the cache are ignored.
+func (cache *SchedulerCache) SetNodeDraining(name string, draining bool) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	if !draining {
+		delete(cache.drainingNodes, name)
+		return
+	}
+	if _, ok := cache.nodesMap[name]; ok {
+		cache.drainingNodes[name] = true
+	}
+}
+
+// GetDrainingNodeNames returns the sorted names of all draining nodes in the cache
+func (cache *SchedulerCache) GetDrainingNodeNames() []string {
+	cache.lock.RLock()
+	defer cache.lock.RUnlock()
+	names := make([]string, 0, len(cache.drainingNodes))
+	for name := range cache.drainingNodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // UpdateNode updates the given node in the cache and returns the previous node if it exists
 func (cache *SchedulerCache) UpdateNode(node *v1.Node) (*v1.Node, []*v1.Pod) {
 	cache.lock.Lock()
@@ -255,6 +296,7 @@ func (cache *SchedulerCache) removeNode(node *v1.Node) (*v1.Node, []*v1.Pod) {
 	delete(cache.nodesMap, node.Name)
 	delete(cache.nodeOccupied, node.Name)
 	delete(cache.nodeCapacity, node.Name)
+	delete(cache.drainingNodes, node.Name)
 	cache.nodesInfo = nil
 	cache.nodesInfoPodsWithAffinity = nil
 	cache.nodesInfoPodsWithReqAntiAffinity = nil
@@ -432,6 +474,60 @@ func (cache *SchedulerCache) GetInProgressPodAllocation(podKey string) (nodeID s
 	return res, ok
 }
 
+// GetPendingAllocationResource is used in scheduler plugin mode to retrieve the combined resources of all pods which
+// have a pending allocation on the given node. Pods which are not in the cache are skipped.
+func (cache *SchedulerCache) GetPendingAllocationResource(nodeID string) *si.Resource {
+	cache.lock.RLock()
+	defer cache.lock.RUnlock()
+	result := common.NewResourceBuilder().Build()
+	for podKey, pendingNodeID := range cache.pendingAllocations {
+		if pendingNodeID != nodeID {
+			continue
+		}
+		if pod, ok := cache.podsMap[podKey]; ok {
+			result = common.Add(result, common.GetPodResource(pod))
+		}
+	}
+	return result
+}
+
+// GetPodAllocations returns the pending and in-progress pod allocations as a map of pod to node ID
+func (cache *SchedulerCache) GetPodAllocations() map[string]string {
+	cache.lock.RLock()
+	defer cache.lock.RUnlock()
+	result := make(map[string]string, len(cache.pendingAllocations)+len(cache.inProgressAllocations))
+	for podKey, nodeID := range cache.pendingAllocations {
+		result[podKey] = nodeID
+	}
+	for podKey, nodeID := range cache.inProgressAllocations {
+		result[podKey] = nodeID
+	}
+	return result
+}
+
+// GetPodsForApplication returns deep copies of the cached pods of the application, sorted by pod UID
+func (cache *SchedulerCache) GetPodsForApplication(appID string) []*v1.Pod {
+	cache.lock.RLock()
+	defer cache.lock.RUnlock()
+	pods := make([]*v1.Pod, 0)
+	for _, pod := range cache.podsMap {
+		if utils.GetApplicationIDFromPod(pod) == appID {
+			pods = append(pods, pod.DeepCopy())
+		}
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].UID < pods[j].UID
+	})
+	return pods
+}
+
+// GetInProgressPodAllocationCount returns the number of pod allocations which are in progress
+func (cache *SchedulerCache) GetInProgressPodAllocationCount() int {
+	cache.lock.RLock()
+	defer cache.lock.RUnlock()
+	return len(cache.inProgressAllocations)
+}
+
 // StartPodAllocation is used in scheduler plugin mode to transition a pod allocation from pending to in-progress. If
 // the given pod has a pending allocation on the given node, the allocation is marked as in-progress and this function
 // returns true. If the pod is not pending or is pending on another node, this function does nothing and returns false.
@@ -21,8 +21,10 @@ package cache
 import (
 	"strings"
 
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	batchListersV1 "k8s.io/client-go/listers/batch/v1"
 
 	"go.uber.org/zap"
 
@@ -82,7 +84,7 @@ func getAppMetadata(pod *v1.Pod) (ApplicationMetadata, bool) {
 	// When we create and link the metadata to an application the application does not exist yet.
 	// The force flag prevents rejections during initialisation of already allocated pods in a changed
 	// queue configuration.
-	// It will also pick up static (mirror) and DaemonSet pods. In certain circumstances this could cause
+	// It will also pick up DaemonSet pods. In certain circumstances this could cause
 	// pods to be allowed into the recovery queue while they should not. If this becomes an issue we can
 	// add a filter here.
 	// NOTE: this could fail to set the flag if the oldest pod for the application is not scheduled and
@@ -102,6 +104,11 @@ func getAppMetadata(pod *v1.Pod) (ApplicationMetadata, bool) {
 		tags[constants.AppTagImagePullSecrets] = strings.Join(arr, ",")
 	}
 
+	// attach the team if the team label is configured and set
+	if team := getTeamFromPod(pod); team != "" {
+		tags[constants.AppTagTeam] = team
+	}
+
 	// get the user from Pod Labels
 	user, groups := utils.GetUserFromPod(pod)
 
@@ -138,6 +145,45 @@ func getAppMetadata(pod *v1.Pod) (ApplicationMetadata, bool) {
 	}, true
 }
 
+// getTeamFromPod returns the value of the configured team label of the pod.
+// Returns an empty string if no team label is configured or the pod does not have it.
+func getTeamFromPod(pod *v1.Pod) string {
+	key := conf.GetSchedulerConf().TeamLabelKey
+	if key == "" || pod == nil {
+		return ""
+	}
+	return pod.Labels[key]
+}
+
+// getWorkloadKindFromPod returns the kind of the top-level workload that created the pod, derived from the controller
+// owner chain of the pod. A ReplicaSet owning a pod with a template hash is reported as its Deployment. The controller
+// of a Job, like a CronJob, is looked up using the job lister. If the lister is nil or the Job is not found the Job is
+// reported. Returns an empty string if the pod has no controller owner.
+func getWorkloadKindFromPod(pod *v1.Pod, jobLister batchListersV1.JobLister) string {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return ""
+	}
+	switch owner.Kind {
+	case "ReplicaSet":
+		if _, ok := pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; ok {
+			return "Deployment"
+		}
+	case "Job":
+		if jobLister == nil {
+			break
+		}
+		job, err := jobLister.Jobs(pod.Namespace).Get(owner.Name)
+		if err != nil || job.UID != owner.UID {
+			break
+		}
+		if jobOwner := metav1.GetControllerOf(job); jobOwner != nil {
+			return jobOwner.Kind
+		}
+	}
+	return owner.Kind
+}
+
 func getOwnerReference(pod *v1.Pod) []metav1.OwnerReference {
 	// Just return the originator pod as the owner of placeholder pods
 	controller := false
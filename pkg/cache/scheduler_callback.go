@@ -84,6 +84,7 @@ func (callback *AsyncRMCallback) UpdateAllocation(response *si.AllocationRespons
 		// request rejected by the scheduler, put it back and try scheduling again
 		log.Log(log.ShimRMCallback).Debug("callback: response to rejected ask",
 			zap.String("allocationKey", reject.AllocationKey))
+		callback.context.recordAllocationRejection(reject.AllocationKey)
 		if app := callback.context.GetApplication(reject.ApplicationID); app != nil {
 			dispatcher.Dispatch(NewRejectTaskEvent(app.GetApplicationID(), reject.AllocationKey,
 				fmt.Sprintf("task %s ask from application %s is rejected by scheduler",
@@ -95,6 +96,7 @@ func (callback *AsyncRMCallback) UpdateAllocation(response *si.AllocationRespons
 		// request rejected by the scheduler, reject it
 		log.Log(log.ShimRMCallback).Debug("callback: response to rejected allocation",
 			zap.String("allocationKey", reject.AllocationKey))
+		callback.context.recordAllocationRejection(reject.AllocationKey)
 		if app := callback.context.GetApplication(reject.ApplicationID); app != nil {
 			dispatcher.Dispatch(NewRejectTaskEvent(app.GetApplicationID(), reject.AllocationKey,
 				fmt.Sprintf("task %s allocation from application %s is rejected by scheduler",
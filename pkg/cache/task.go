@@ -27,37 +27,61 @@ import (
 	"github.com/looplab/fsm"
 	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
 
 	"github.com/apache/yunikorn-k8shim/pkg/common"
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
 	"github.com/apache/yunikorn-k8shim/pkg/common/events"
 	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
 	"github.com/apache/yunikorn-k8shim/pkg/dispatcher"
 	"github.com/apache/yunikorn-k8shim/pkg/locking"
 	"github.com/apache/yunikorn-k8shim/pkg/log"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
 )
 
+// releaseRetryBackoff is the initial wait before a failed release request is retried
+var releaseRetryBackoff = 100 * time.Millisecond
+
+// maxTaskTransitions is the number of state transitions kept per task
+const maxTaskTransitions = 100
+
 type Task struct {
-	taskID          string
-	alias           string
-	applicationID   string
-	application     *Application
-	allocationKey   string
-	resource        *si.Resource
-	pod             *v1.Pod
-	podStatus       v1.PodStatus // pod status, maintained separately for efficiency reasons
-	context         *Context
-	nodeName        string
-	createTime      time.Time
-	taskGroupName   string
-	placeholder     bool
-	terminationType string
-	originator      bool
-	schedulingState TaskSchedulingState
-	sm              *fsm.FSM
-	lock            *locking.RWMutex
+	taskID            string
+	alias             string
+	applicationID     string
+	application       *Application
+	allocationKey     string
+	resource          *si.Resource
+	pod               *v1.Pod
+	podStatus         v1.PodStatus // pod status, maintained separately for efficiency reasons
+	context           *Context
+	nodeName          string
+	createTime        time.Time
+	taskGroupName     string
+	placeholder       bool
+	terminationType   string
+	originator        bool
+	schedulingState   TaskSchedulingState
+	origin            TaskOrigin
+	bindFailureReason string        // reason of the last failed volume or pod bind
+	imagePullBackOff  time.Time     // first time the pod was seen in an image pull back-off, zero if not in back-off
+	schedulingCycles  int           // number of application scheduling cycles that considered the task
+	waitReason        string        // most recent reason reported by the core for not scheduling the task
+	releaseBackoff    *wait.Backoff // backoff of the completion release retries, nil if no retry is pending
+	transitions       []TaskTransition
+	sm                *fsm.FSM
+	lock              *locking.RWMutex
+}
+
+// TaskTransition records a single state transition of a task
+type TaskTransition struct {
+	TaskID string
+	From   string
+	To     string
+	Event  string
+	Time   time.Time
 }
 
 func NewTask(tid string, app *Application, ctx *Context, pod *v1.Pod) *Task {
@@ -86,6 +110,9 @@ func NewFromTaskMeta(tid string, app *Application, ctx *Context, metadata TaskMe
 
 func createTaskInternal(tid string, app *Application, resource *si.Resource,
 	pod *v1.Pod, placeholder bool, taskGroupName string, ctx *Context, originator bool) *Task {
+	if conf.GetSchedulerConf().DropZeroResourceRequests {
+		resource = common.DropZeroResources(resource)
+	}
 	task := &Task{
 		taskID:          tid,
 		alias:           fmt.Sprintf("%s/%s", pod.Namespace, pod.Name),
@@ -151,6 +178,93 @@ func (task *Task) GetTaskState() string {
 	return task.sm.Current()
 }
 
+// GetBindFailureReason returns the reason the last volume or pod bind of the task failed.
+// An empty string is returned if no bind has failed.
+func (task *Task) GetBindFailureReason() string {
+	task.lock.RLock()
+	defer task.lock.RUnlock()
+	return task.bindFailureReason
+}
+
+// GetSchedulingCycles returns the number of application scheduling cycles that considered the task
+// while it was waiting for an allocation.
+func (task *Task) GetSchedulingCycles() int {
+	task.lock.RLock()
+	defer task.lock.RUnlock()
+	return task.schedulingCycles
+}
+
+func (task *Task) incSchedulingCycles() {
+	task.lock.Lock()
+	defer task.lock.Unlock()
+	task.schedulingCycles++
+}
+
+// GetWaitReason returns the most recent reason the core reported for not scheduling the task.
+func (task *Task) GetWaitReason() string {
+	task.lock.RLock()
+	defer task.lock.RUnlock()
+	return task.waitReason
+}
+
+func (task *Task) setWaitReason(reason string) {
+	task.lock.Lock()
+	defer task.lock.Unlock()
+	task.waitReason = reason
+}
+
+// trackImagePullBackOff records if the pod of the task is in an image pull back-off. It returns for how long
+// the pod has been in the back-off, or zero if the pod is not in back-off.
+func (task *Task) trackImagePullBackOff(inBackOff bool, now time.Time) time.Duration {
+	task.lock.Lock()
+	defer task.lock.Unlock()
+	if !inBackOff {
+		task.imagePullBackOff = time.Time{}
+		return 0
+	}
+	if task.imagePullBackOff.IsZero() {
+		task.imagePullBackOff = now
+	}
+	return now.Sub(task.imagePullBackOff)
+}
+
+// GetTransitions returns the state transitions of the task, oldest first
+func (task *Task) GetTransitions() []TaskTransition {
+	task.lock.RLock()
+	defer task.lock.RUnlock()
+	transitions := make([]TaskTransition, len(task.transitions))
+	copy(transitions, task.transitions)
+	return transitions
+}
+
+// GetCreationToBindLatency returns the time between the creation of the task's pod and the task being bound.
+// The boolean is false if the task was never bound by the shim.
+func (task *Task) GetCreationToBindLatency() (time.Duration, bool) {
+	task.lock.RLock()
+	defer task.lock.RUnlock()
+	for i := len(task.transitions) - 1; i >= 0; i-- {
+		if task.transitions[i].To == TaskStates().Bound {
+			return task.transitions[i].Time.Sub(task.createTime), true
+		}
+	}
+	return 0, false
+}
+
+// recordTransition is called from the state machine callbacks, the task lock is already held.
+// Only the last maxTaskTransitions transitions are kept.
+func (task *Task) recordTransition(from, to, event string) {
+	if len(task.transitions) >= maxTaskTransitions {
+		task.transitions = task.transitions[1:]
+	}
+	task.transitions = append(task.transitions, TaskTransition{
+		TaskID: task.taskID,
+		From:   from,
+		To:     to,
+		Event:  event,
+		Time:   timeNow(),
+	})
+}
+
 func (task *Task) setTaskGroupName(groupName string) {
 	task.lock.Lock()
 	defer task.lock.Unlock()
@@ -175,6 +289,32 @@ func (task *Task) getNodeName() string {
 	return task.nodeName
 }
 
+func (task *Task) getResource() *si.Resource {
+	task.lock.RLock()
+	defer task.lock.RUnlock()
+	return task.resource
+}
+
+// GetOrigin returns whether the task was created for a new or a recovered pod
+func (task *Task) GetOrigin() TaskOrigin {
+	task.lock.RLock()
+	defer task.lock.RUnlock()
+	return task.origin
+}
+
+func (task *Task) setOrigin(origin TaskOrigin) {
+	task.lock.Lock()
+	defer task.lock.Unlock()
+	task.origin = origin
+}
+
+// getPriority returns the priority of the task as it is communicated to the core
+func (task *Task) getPriority() int32 {
+	task.lock.RLock()
+	defer task.lock.RUnlock()
+	return common.CreatePriorityForTask(task.pod)
+}
+
 func (task *Task) DeleteTaskPod() error {
 	return task.context.apiProvider.GetAPIs().KubeClient.Delete(task.pod)
 }
@@ -221,6 +361,20 @@ func (task *Task) initialize() {
 	}
 }
 
+// failOnCreate moves a newly created task directly into the Failed state. The task has never been
+// submitted to the core so there is nothing to release.
+func (task *Task) failOnCreate(errorMessage, actionReason string) {
+	task.lock.Lock()
+	defer task.lock.Unlock()
+	task.sm.SetState(TaskStates().Failed)
+	log.Log(log.ShimCacheTask).Warn("task failed on creation",
+		zap.String("appID", task.applicationID),
+		zap.String("taskID", task.taskID),
+		zap.String("reason", errorMessage))
+	events.GetRecorder().Eventf(task.pod.DeepCopy(),
+		nil, v1.EventTypeWarning, actionReason, actionReason, errorMessage)
+}
+
 func (task *Task) IsOriginator() bool {
 	task.lock.RLock()
 	defer task.lock.RUnlock()
@@ -234,9 +388,12 @@ func (task *Task) isPreemptSelfAllowed() bool {
 		return true
 	case constants.False:
 		return false
-	default:
-		return task.context.IsPreemptSelfAllowed(task.pod.Spec.PriorityClassName)
 	}
+	// pod annotation not set, an opt-out on the priority class takes precedence over the namespace default
+	if allowed, ok := task.context.getPriorityClassPreemption(task.pod.Spec.PriorityClassName); ok {
+		return allowed
+	}
+	return task.application.GetTags()[constants.AppTagNamespaceAllowPreemption] != constants.False
 }
 
 func (task *Task) isPreemptOtherAllowed() bool {
@@ -254,6 +411,29 @@ func (task *Task) isPreemptOtherAllowed() bool {
 	}
 }
 
+// updatePreemptionPolicy re-sends the allocation of a bound task to the core with the current preemption policy.
+func (task *Task) updatePreemptionPolicy() error {
+	// evaluated before locking the task: the self preemption check locks the application
+	preemptionPolicy := &si.PreemptionPolicy{
+		AllowPreemptSelf:  task.isPreemptSelfAllowed(),
+		AllowPreemptOther: task.isPreemptOtherAllowed(),
+	}
+	task.lock.RLock()
+	rr := common.CreateAllocationForTask(
+		task.applicationID,
+		task.taskID,
+		task.nodeName,
+		task.resource,
+		task.placeholder,
+		task.taskGroupName,
+		task.pod,
+		task.originator,
+		preemptionPolicy)
+	task.lock.RUnlock()
+	log.Log(log.ShimCacheTask).Debug("send allocation preemption policy update", zap.Stringer("request", rr))
+	return task.context.updateAllocation(rr)
+}
+
 func (task *Task) SetTaskSchedulingState(state TaskSchedulingState) {
 	task.lock.Lock()
 	defer task.lock.Unlock()
@@ -305,7 +485,7 @@ func (task *Task) handleSubmitTaskEvent() {
 			task.originator,
 			preemptionPolicy)
 		log.Log(log.ShimCacheTask).Debug("send update request", zap.Stringer("request", rr))
-		if err := task.context.apiProvider.GetAPIs().SchedulerAPI.UpdateAllocation(rr); err != nil {
+		if err := task.context.updateAllocation(rr); err != nil {
 			log.Log(log.ShimCacheTask).Debug("failed to send allocation to scheduler", zap.Error(err))
 			return
 		}
@@ -321,7 +501,7 @@ func (task *Task) handleSubmitTaskEvent() {
 			task.originator,
 			preemptionPolicy)
 		log.Log(log.ShimCacheTask).Debug("send update request", zap.Stringer("request", rr))
-		if err := task.context.apiProvider.GetAPIs().SchedulerAPI.UpdateAllocation(rr); err != nil {
+		if err := task.context.updateAllocation(rr); err != nil {
 			log.Log(log.ShimCacheTask).Debug("failed to send scheduling request to scheduler", zap.Error(err))
 			return
 		}
@@ -375,12 +555,23 @@ func (task *Task) postTaskAllocated() {
 				nil, v1.EventTypeNormal, "Scheduled", "Scheduled",
 				"Successfully assigned %s to node %s", task.alias, task.nodeName)
 
+			// pod was created with the node name set or bound before, nothing left to bind
+			if task.pod.Spec.NodeName != "" && task.pod.Spec.NodeName == task.nodeName {
+				log.Log(log.ShimCacheTask).Info("pod already assigned to node, skipping bind",
+					zap.String("podName", task.pod.Name),
+					zap.String("nodeName", task.nodeName))
+				dispatcher.Dispatch(NewBindTaskEvent(task.applicationID, task.taskID))
+				task.schedulingState = TaskSchedAllocated
+				return
+			}
+
 			// before binding pod to node, first bind volumes to pod
 			log.Log(log.ShimCacheTask).Debug("bind pod volumes",
 				zap.String("podName", task.pod.Name),
 				zap.String("podUID", string(task.pod.UID)))
 			if err := task.context.bindPodVolumes(task.pod); err != nil {
 				log.Log(log.ShimCacheTask).Error("bind volumes to pod failed", zap.String("taskID", task.taskID), zap.Error(err))
+				task.bindFailureReason = fmt.Sprintf("bind volumes to pod failed: %s", err.Error())
 				task.failWithEvent(fmt.Sprintf("bind volumes to pod failed, name: %s, %s", task.alias, err.Error()), "PodVolumesBindFailure")
 				return
 			}
@@ -391,6 +582,7 @@ func (task *Task) postTaskAllocated() {
 
 			if err := task.context.apiProvider.GetAPIs().KubeClient.Bind(task.pod, task.nodeName); err != nil {
 				log.Log(log.ShimCacheTask).Error("bind pod to node failed", zap.String("taskID", task.taskID), zap.Error(err))
+				task.bindFailureReason = fmt.Sprintf("bind pod to node failed: %s", err.Error())
 				task.failWithEvent(fmt.Sprintf("bind pod to node failed, name: %s, %s", task.alias, err.Error()), "PodBindFailure")
 				return
 			}
@@ -406,6 +598,19 @@ func (task *Task) postTaskAllocated() {
 	}()
 }
 
+// annotateQueue patches the pod with the queue of the application the task belongs to.
+func (task *Task) annotateQueue(pod *v1.Pod) {
+	queue := task.application.GetQueue()
+	if err := task.context.apiProvider.GetAPIs().KubeClient.PatchPodAnnotations(pod, map[string]string{
+		constants.AnnotationEffectiveQueue: queue,
+	}); err != nil {
+		log.Log(log.ShimCacheTask).Warn("failed to annotate pod with queue",
+			zap.String("podName", pod.Name),
+			zap.String("queue", queue),
+			zap.Error(err))
+	}
+}
+
 // beforeTaskAllocated is called before handling the TaskAllocated event.
 // This sets the allocation information returned by the core in the task.
 // In some cases, the task is canceled (e.g. pod deleted) before we process the allocation
@@ -430,6 +635,8 @@ func (task *Task) beforeTaskAllocated(eventSrc string, allocationKey string, nod
 }
 
 func (task *Task) postTaskBound() {
+	task.application.resetScheduleAttempts()
+	task.application.recordFirstBind()
 	if utils.IsPluginMode() {
 		// When the pod is actively scheduled by YuniKorn, it can be  moved to the default-scheduler's
 		// UnschedulablePods structure. If the pod does not change, the pod will stay in the UnschedulablePods
@@ -448,6 +655,17 @@ func (task *Task) postTaskBound() {
 		}
 	}
 
+	if conf.GetSchedulerConf().AnnotateBoundPodQueue {
+		// the task lock is held here: annotate asynchronously to not take the application lock
+		// and to not block the state machine on the API call
+		go task.annotateQueue(task.pod)
+	}
+
+	if conf.GetSchedulerConf().EmitBindingDecisionEvents && task.context != nil {
+		// the task lock is held here: scoring takes the context lock and runs asynchronously
+		go task.context.publishBindingDecision(task, task.pod.DeepCopy(), task.nodeName)
+	}
+
 	if task.placeholder {
 		log.Log(log.ShimCacheTask).Info("placeholder is bound",
 			zap.String("appID", task.applicationID),
@@ -476,6 +694,18 @@ func (task *Task) beforeTaskFail() {
 	task.releaseAllocation()
 }
 
+// beforeTaskReschedule releases the ask or allocation from the scheduler core, entering the Pending state
+// afterwards submits the task again. A bound pod cannot move: it is re-submitted as an allocation on its node.
+func (task *Task) beforeTaskReschedule(src string) {
+	task.releaseAllocation()
+	if src == TaskStates().Bound && !utils.IsAssignedPod(task.pod) {
+		pod := task.pod.DeepCopy()
+		pod.Spec.NodeName = task.nodeName
+		task.pod = pod
+	}
+	task.schedulingState = TaskSchedPending
+}
+
 func (task *Task) postTaskFailed(reason string) {
 	log.Log(log.ShimCacheTask).Error("task failed",
 		zap.String("appID", task.applicationID),
@@ -490,15 +720,60 @@ func (task *Task) postTaskFailed(reason string) {
 // this is done as a before hook because the releaseAllocation() call needs to
 // send different requests to scheduler-core, depending on current task state
 func (task *Task) beforeTaskCompleted() {
-	task.releaseAllocation()
+	retry := task.releaseBackoff != nil
+	if err := task.releaseAllocation(); err != nil && isTransientError(err) {
+		task.retryCompletion()
+	} else {
+		task.releaseBackoff = nil
+	}
+	if retry {
+		return
+	}
 
 	events.GetRecorder().Eventf(task.pod.DeepCopy(), nil,
 		v1.EventTypeNormal, "TaskCompleted", "TaskCompleted",
 		"Task %s is completed", task.alias)
 }
 
+// retryCompletion dispatches the completion of the task again after a backoff, which re-sends the release
+// request to the core. Called from the state machine callbacks while the task lock is held.
+func (task *Task) retryCompletion() {
+	retries := conf.GetSchedulerConf().TaskReleaseMaxRetries
+	if retries <= 0 {
+		return
+	}
+	if task.releaseBackoff == nil {
+		backoff := newRetryBackoff(releaseRetryBackoff, retries)
+		task.releaseBackoff = &backoff
+	}
+	if task.releaseBackoff.Steps == 0 {
+		log.Log(log.ShimCacheTask).Error("giving up sending release request",
+			zap.String("applicationID", task.applicationID),
+			zap.String("taskID", task.taskID))
+		task.releaseBackoff = nil
+		return
+	}
+	delay := task.releaseBackoff.Step()
+	log.Log(log.ShimCacheTask).Warn("failed to send release request, retrying",
+		zap.String("applicationID", task.applicationID),
+		zap.String("taskID", task.taskID),
+		zap.Duration("backoff", delay))
+	time.AfterFunc(delay, func() {
+		dispatcher.Dispatch(NewSimpleTaskEvent(task.applicationID, task.taskID, CompleteTask))
+	})
+}
+
+// isReleasePending returns true if a failed release request of the task is being retried.
+// The task must not be removed from the application until the retries are done.
+func (task *Task) isReleasePending() bool {
+	task.lock.RLock()
+	defer task.lock.RUnlock()
+	return task.releaseBackoff != nil
+}
+
 // releaseAllocation sends the release request for the Allocation or the AllocationAsk to the core.
-func (task *Task) releaseAllocation() {
+// The error of the call to the core is returned.
+func (task *Task) releaseAllocation() error {
 	// scheduler api might be nil in some tests
 	if task.context.apiProvider.GetAPIs().SchedulerAPI != nil {
 		log.Log(log.ShimCacheTask).Debug("prepare to send release request",
@@ -534,10 +809,12 @@ func (task *Task) releaseAllocation() {
 				zap.Int("numOfAsksToRelease", len(releaseRequest.Releases.AllocationAsksToRelease)),
 				zap.Int("numOfAllocationsToRelease", len(releaseRequest.Releases.AllocationsToRelease)))
 		}
-		if err := task.context.apiProvider.GetAPIs().SchedulerAPI.UpdateAllocation(releaseRequest); err != nil {
+		if err := task.context.TrackSchedulerCall(task.context.apiProvider.GetAPIs().SchedulerAPI.UpdateAllocation(releaseRequest)); err != nil {
 			log.Log(log.ShimCacheTask).Debug("failed to send scheduling request to scheduler", zap.Error(err))
+			return err
 		}
 	}
+	return nil
 }
 
 // some sanity checks before sending task for scheduling,
@@ -588,6 +865,12 @@ func (task *Task) UpdatePodCondition(podCondition *v1.PodCondition) (bool, *v1.P
 	return false, pod
 }
 
+func (task *Task) getAllocationKey() string {
+	task.lock.RLock()
+	defer task.lock.RUnlock()
+	return task.allocationKey
+}
+
 func (task *Task) setAllocationKey(allocationKey string) {
 	task.lock.Lock()
 	defer task.lock.Unlock()
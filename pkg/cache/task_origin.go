@@ -0,0 +1,33 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package cache
+
+// TaskOrigin describes how a task was added to the shim
+type TaskOrigin int8
+
+const (
+	TaskOriginNew       TaskOrigin = iota // task for a newly submitted pod
+	TaskOriginRecovered                   // task for a pod that was already assigned or running
+)
+
+var taskOriginNames = []string{"New", "Recovered"}
+
+func (to TaskOrigin) String() string {
+	return taskOriginNames[to]
+}
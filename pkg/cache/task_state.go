@@ -47,10 +47,12 @@ const (
 	TaskFail
 	KillTask
 	TaskKilled
+	FailBoundTask
+	RescheduleTask
 )
 
 func (ae TaskEventType) String() string {
-	return [...]string{"InitTask", "SubmitTask", "TaskAllocated", "TaskRejected", "TaskBound", "CompleteTask", "TaskFail", "KillTask", "TaskKilled"}[ae]
+	return [...]string{"InitTask", "SubmitTask", "TaskAllocated", "TaskRejected", "TaskBound", "CompleteTask", "TaskFail", "KillTask", "TaskKilled", "FailBoundTask", "RescheduleTask"}[ae]
 }
 
 // ------------------------
@@ -211,6 +213,17 @@ func NewFailTaskEvent(appID string, taskID string, failedMessage string) FailTas
 	}
 }
 
+// NewFailBoundTaskEvent creates the event to fail a task that is already bound to a node. Only paths that detect a
+// bound pod which can never run use it, a FailTaskEvent is ignored for bound tasks.
+func NewFailBoundTaskEvent(appID string, taskID string, failedMessage string) FailTaskEvent {
+	return FailTaskEvent{
+		applicationID: appID,
+		taskID:        taskID,
+		event:         FailBoundTask,
+		message:       failedMessage,
+	}
+}
+
 func (fe FailTaskEvent) GetEvent() string {
 	return fe.event.String()
 }
@@ -368,6 +381,16 @@ func newTaskState() *fsm.FSM {
 				Src:  []string{states.New, states.Pending, states.Scheduling, states.Rejected, states.Allocated},
 				Dst:  states.Failed,
 			},
+			{
+				Name: FailBoundTask.String(),
+				Src:  []string{states.Bound},
+				Dst:  states.Failed,
+			},
+			{
+				Name: RescheduleTask.String(),
+				Src:  []string{states.Scheduling, states.Bound},
+				Dst:  states.Pending,
+			},
 		},
 		fsm.Callbacks{
 			// The state machine is tightly tied to the Task object.
@@ -384,6 +407,8 @@ func newTaskState() *fsm.FSM {
 					zap.String("source", event.Src),
 					zap.String("destination", event.Dst),
 					zap.String("event", event.Event))
+				task.recordTransition(event.Src, event.Dst, event.Event)
+				task.application.recordActivity()
 			},
 			states.Pending: func(_ context.Context, event *fsm.Event) {
 				task := event.Args[0].(*Task) //nolint:errcheck
@@ -417,6 +442,14 @@ func newTaskState() *fsm.FSM {
 				task := event.Args[0].(*Task) //nolint:errcheck
 				task.beforeTaskFail()
 			},
+			beforeHook(FailBoundTask): func(_ context.Context, event *fsm.Event) {
+				task := event.Args[0].(*Task) //nolint:errcheck
+				task.beforeTaskFail()
+			},
+			beforeHook(RescheduleTask): func(_ context.Context, event *fsm.Event) {
+				task := event.Args[0].(*Task) //nolint:errcheck
+				task.beforeTaskReschedule(event.Src)
+			},
 			beforeHook(TaskAllocated): func(_ context.Context, event *fsm.Event) {
 				task := event.Args[0].(*Task) //nolint:errcheck
 				// All allocation events must include the allocationKey and nodeID passed from the core
@@ -19,6 +19,7 @@
 package cache
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -34,6 +35,7 @@ import (
 	"github.com/apache/yunikorn-k8shim/pkg/client"
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
 	"github.com/apache/yunikorn-k8shim/pkg/common/events"
+	"github.com/apache/yunikorn-k8shim/pkg/common/utils"
 	"github.com/apache/yunikorn-k8shim/pkg/conf"
 	"github.com/apache/yunikorn-k8shim/pkg/locking"
 
@@ -780,3 +782,86 @@ func TestUpdatePodCondition(t *testing.T) {
 	assert.Equal(t, v1.PodPending, podCopy.Status.Phase)
 	assert.Equal(t, v1.PodReasonUnschedulable, podCopy.Status.Conditions[0].Reason)
 }
+
+func TestBindFailureReason(t *testing.T) {
+	context, apiProvider := initContextAndAPIProviderForTest()
+	apiProvider.MockBindFn(func(pod *v1.Pod, hostID string) error {
+		return errors.New("node not ready")
+	})
+	app := NewApplication(appID1, "root.default", "user", testGroups, map[string]string{}, apiProvider.GetAPIs().SchedulerAPI)
+	context.addApplicationToContext(app)
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "pod-bind-failure",
+			UID:  "UID-00001",
+		},
+	}
+	task := NewTask("task01", app, context, pod)
+	app.addTask(task)
+	task.nodeName = "node-1"
+	assert.Equal(t, task.GetBindFailureReason(), "")
+
+	task.postTaskAllocated()
+	err := utils.WaitForCondition(func() bool {
+		return task.GetBindFailureReason() != ""
+	}, 10*time.Millisecond, time.Second)
+	assert.NilError(t, err, "bind failure reason was not recorded")
+	assert.Equal(t, task.GetBindFailureReason(), "bind pod to node failed: node not ready")
+	assert.Equal(t, context.GetTaskBindFailureReason(appID1, "task01"), "bind pod to node failed: node not ready")
+	assert.Equal(t, context.GetTaskBindFailureReason(appID1, "unknown"), "")
+}
+
+func TestAnnotateBoundPodQueue(t *testing.T) {
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.AnnotateBoundPodQueue = true
+	})
+
+	context, apiProvider := initContextAndAPIProviderForTest()
+	patched := make(chan map[string]string, 1)
+	apiProvider.MockPatchPodAnnotationsFn(func(pod *v1.Pod, annotations map[string]string) error {
+		patched <- annotations
+		return nil
+	})
+	app := NewApplication(appID1, "root.default", "user", testGroups, map[string]string{}, apiProvider.GetAPIs().SchedulerAPI)
+	context.addApplicationToContext(app)
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "pod-annotate-queue",
+			UID:  "UID-00001",
+		},
+	}
+	task := NewTask("task01", app, context, pod)
+	app.addTask(task)
+	task.sm.SetState(TaskStates().Allocated)
+
+	err := task.handle(NewBindTaskEvent(appID1, "task01"))
+	assert.NilError(t, err, "failed to handle BindTask event")
+	assert.Equal(t, task.GetTaskState(), TaskStates().Bound)
+
+	select {
+	case annotations := <-patched:
+		assert.DeepEqual(t, annotations, map[string]string{constants.AnnotationEffectiveQueue: "root.default"})
+	case <-time.After(time.Second):
+		t.Fatal("pod was not patched with the queue annotation")
+	}
+}
+
+func TestTaskTransitionsBounded(t *testing.T) {
+	context := initContextForTest()
+	app := NewApplication(appID1, "root.default", "user", testGroups, map[string]string{}, newMockSchedulerAPI())
+	context.addApplicationToContext(app)
+	task := NewTask("task01", app, context, &v1.Pod{})
+	app.addTask(task)
+	task.sm.SetState(TaskStates().Scheduling)
+
+	// every reschedule and submit of the task records a transition
+	for i := 0; i < maxTaskTransitions; i++ {
+		assert.NilError(t, task.handle(NewSimpleTaskEvent(appID1, "task01", RescheduleTask)))
+		assert.NilError(t, task.handle(NewSimpleTaskEvent(appID1, "task01", SubmitTask)))
+	}
+	transitions := task.GetTransitions()
+	assert.Equal(t, len(transitions), maxTaskTransitions)
+	assert.Equal(t, transitions[0].Event, RescheduleTask.String())
+	assert.Equal(t, transitions[maxTaskTransitions-1].Event, SubmitTask.String())
+	assert.Equal(t, transitions[maxTaskTransitions-1].To, TaskStates().Scheduling)
+}
@@ -23,6 +23,7 @@ import (
 
 	"go.uber.org/zap"
 	"k8s.io/client-go/informers"
+	batchInformerV1 "k8s.io/client-go/informers/batch/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/volumebinding"
@@ -35,7 +36,7 @@ import (
 
 type Type int
 
-var informerTypes = [...]string{"Pod", "Node", "ConfigMap", "Storage", "PV", "PVC", "PriorityClass"}
+var informerTypes = [...]string{"Pod", "Node", "ConfigMap", "Storage", "PV", "PVC", "PriorityClass", "Namespace"}
 
 const (
 	PodInformerHandlers Type = iota
@@ -45,6 +46,7 @@ const (
 	PVInformerHandlers
 	PVCInformerHandlers
 	PriorityClassInformerHandlers
+	NamespaceInformerHandlers
 )
 
 func (t Type) String() string {
@@ -93,6 +95,11 @@ func NewAPIFactory(scheduler api.SchedulerAPI, informerFactory informers.SharedI
 	pvcInformer := informerFactory.Core().V1().PersistentVolumeClaims()
 	namespaceInformer := informerFactory.Core().V1().Namespaces()
 	priorityClassInformer := informerFactory.Scheduling().V1().PriorityClasses()
+	// jobs are only needed to find the owner of a job when tagging the workload kind
+	var jobInformer batchInformerV1.JobInformer
+	if configs.TagWorkloadKind {
+		jobInformer = informerFactory.Batch().V1().Jobs()
+	}
 
 	var capacityCheck = volumebinding.CapacityCheck{
 		CSIDriverInformer:          informerFactory.Storage().V1().CSIDrivers(),
@@ -126,6 +133,7 @@ func NewAPIFactory(scheduler api.SchedulerAPI, informerFactory informers.SharedI
 			NamespaceInformer:     namespaceInformer,
 			StorageInformer:       storageInformer,
 			PriorityClassInformer: priorityClassInformer,
+			JobInformer:           jobInformer,
 			VolumeBinder:          volumeBinder,
 		},
 		testMode: testMode,
@@ -192,6 +200,9 @@ func (s *APIFactory) addEventHandlers(
 	case PriorityClassInformerHandlers:
 		s.GetAPIs().PriorityClassInformer.Informer().
 			AddEventHandlerWithResyncPeriod(handler, resyncPeriod)
+	case NamespaceInformerHandlers:
+		s.GetAPIs().NamespaceInformer.Informer().
+			AddEventHandlerWithResyncPeriod(handler, resyncPeriod)
 	}
 }
 
@@ -64,6 +64,7 @@ const (
 )
 
 func NewMockedAPIProvider(showError bool) *MockedAPIProvider {
+	informerFactory := informers.NewSharedInformerFactory(k8fake.NewSimpleClientset(), time.Second*60)
 	return &MockedAPIProvider{
 		clients: &Clients{
 			conf: &conf.SchedulerConf{
@@ -91,7 +92,8 @@ func NewMockedAPIProvider(showError bool) *MockedAPIProvider {
 			VolumeBinder:          test.NewVolumeBinderMock(),
 			NamespaceInformer:     test.NewMockNamespaceInformer(false),
 			PriorityClassInformer: test.NewMockPriorityClassInformer(),
-			InformerFactory:       informers.NewSharedInformerFactory(k8fake.NewSimpleClientset(), time.Second*60),
+			JobInformer:           informerFactory.Batch().V1().Jobs(),
+			InformerFactory:       informerFactory,
 		},
 		events:       make(chan informerEvent),
 		eventHandler: make(chan *ResourceEventHandlers),
@@ -126,6 +128,12 @@ func (m *MockedAPIProvider) GetSchedulerAPIUpdateApplicationCount() int32 {
 	return int32(0)
 }
 
+func (m *MockedAPIProvider) MockSchedulerAPIUpdateConfigurationFn(ufn func(request *si.UpdateConfigurationRequest) error) {
+	if mock, ok := m.clients.SchedulerAPI.(*test.SchedulerAPIMock); ok {
+		mock.UpdateConfigurationFunction(ufn)
+	}
+}
+
 func (m *MockedAPIProvider) MockSchedulerAPIUpdateNodeFn(ufn func(request *si.NodeRequest) error) {
 	if mock, ok := m.clients.SchedulerAPI.(*test.SchedulerAPIMock); ok {
 		mock.UpdateNodeFunction(ufn)
@@ -170,6 +178,12 @@ func (m *MockedAPIProvider) MockUpdateStatusFn(cfn func(pod *v1.Pod) (*v1.Pod, e
 	}
 }
 
+func (m *MockedAPIProvider) MockPatchPodAnnotationsFn(pfn func(pod *v1.Pod, annotations map[string]string) error) {
+	if mock, ok := m.clients.KubeClient.(*KubeClientMock); ok {
+		mock.patchFn = pfn
+	}
+}
+
 func (m *MockedAPIProvider) MockGetFn(cfn func(podName string) (*v1.Pod, error)) {
 	if mock, ok := m.clients.KubeClient.(*KubeClientMock); ok {
 		mock.getFn = cfn
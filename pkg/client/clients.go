@@ -24,6 +24,7 @@ import (
 	"go.uber.org/zap"
 
 	"k8s.io/client-go/informers"
+	batchInformerV1 "k8s.io/client-go/informers/batch/v1"
 	coreInformerV1 "k8s.io/client-go/informers/core/v1"
 	schedulingInformerV1 "k8s.io/client-go/informers/scheduling/v1"
 	storageInformerV1 "k8s.io/client-go/informers/storage/v1"
@@ -57,6 +58,7 @@ type Clients struct {
 	StorageInformer       storageInformerV1.StorageClassInformer
 	NamespaceInformer     coreInformerV1.NamespaceInformer
 	PriorityClassInformer schedulingInformerV1.PriorityClassInformer
+	JobInformer           batchInformerV1.JobInformer // only set if workload kind tagging is enabled
 
 	// volume binder handles PV/PVC related operations
 	VolumeBinder volumebinding.SchedulerVolumeBinder
@@ -77,7 +79,8 @@ func (c *Clients) WaitForSync() {
 			c.StorageInformer.Informer().HasSynced() &&
 			c.ConfigMapInformer.Informer().HasSynced() &&
 			c.NamespaceInformer.Informer().HasSynced() &&
-			c.PriorityClassInformer.Informer().HasSynced() {
+			c.PriorityClassInformer.Informer().HasSynced() &&
+			(c.JobInformer == nil || c.JobInformer.Informer().HasSynced()) {
 			return
 		}
 		time.Sleep(time.Second)
@@ -98,4 +101,7 @@ func (c *Clients) Run(stopCh <-chan struct{}) {
 	go c.ConfigMapInformer.Informer().Run(stopCh)
 	go c.NamespaceInformer.Informer().Run(stopCh)
 	go c.PriorityClassInformer.Informer().Run(stopCh)
+	if c.JobInformer != nil {
+		go c.JobInformer.Informer().Run(stopCh)
+	}
 }
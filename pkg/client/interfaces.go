@@ -40,6 +40,9 @@ type KubeClient interface {
 	// Update the status of a pod
 	UpdateStatus(pod *v1.Pod) (*v1.Pod, error)
 
+	// Add or update annotations of a pod using a merge patch
+	PatchPodAnnotations(pod *v1.Pod, annotations map[string]string) error
+
 	// Get a pod
 	Get(podNamespace string, podName string) (*v1.Pod, error)
 
@@ -39,6 +39,7 @@ type KubeClientMock struct {
 	createFn       func(pod *v1.Pod) (*v1.Pod, error)
 	updateFn       func(pod *v1.Pod, podMutator func(pod *v1.Pod)) (*v1.Pod, error)
 	updateStatusFn func(pod *v1.Pod) (*v1.Pod, error)
+	patchFn        func(pod *v1.Pod, annotations map[string]string) error
 	getFn          func(podName string) (*v1.Pod, error)
 	clientSet      kubernetes.Interface
 	pods           map[string]*v1.Pod
@@ -97,6 +98,14 @@ func NewKubeClientMock(err bool) *KubeClientMock {
 				zap.String("PodName", pod.Name))
 			return pod, nil
 		},
+		patchFn: func(pod *v1.Pod, annotations map[string]string) error {
+			if err {
+				return fmt.Errorf("error patching pod")
+			}
+			log.Log(log.Test).Info("pod annotations patched",
+				zap.String("PodName", pod.Name))
+			return nil
+		},
 		getFn: func(podName string) (*v1.Pod, error) {
 			if err {
 				return nil, fmt.Errorf("error getting pod")
@@ -180,6 +189,12 @@ func (c *KubeClientMock) UpdateStatus(pod *v1.Pod) (*v1.Pod, error) {
 	return c.updateStatusFn(pod)
 }
 
+func (c *KubeClientMock) PatchPodAnnotations(pod *v1.Pod, annotations map[string]string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.patchFn(pod, annotations)
+}
+
 func (c *KubeClientMock) Get(podNamespace string, podName string) (*v1.Pod, error) {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
@@ -29,6 +29,7 @@ const False = "false"
 // Cluster
 const DefaultNodeAttributeHostNameKey = "si.io/hostname"
 const DefaultNodeAttributeRackNameKey = "si.io/rackname"
+const DefaultNodeAttributeDomainKey = "si.io/domain"
 const DefaultNodeInstanceTypeNodeLabelKey = "node.kubernetes.io/instance-type"
 const DefaultRackName = "/rack-default"
 const DomainYuniKorn = siCommon.DomainYuniKorn
@@ -41,11 +42,29 @@ const LabelQueueName = "queue"
 const RootQueue = "root"
 const AnnotationQueueName = DomainYuniKorn + "queue"
 const AnnotationParentQueue = DomainYuniKorn + "parentqueue"
+
+// AnnotationEffectiveQueue set on bound pods, the queue the pod was scheduled in
+const AnnotationEffectiveQueue = DomainYuniKorn + "effective-queue"
+
+// AnnotationEstimatedWait set on the first pod of an application, the estimated time the application waits to be scheduled
+const AnnotationEstimatedWait = DomainYuniKorn + "estimated-wait"
 const ApplicationDefaultQueue = "root.default"
 const DefaultPartition = "default"
 const AppTagNamespace = "namespace"
 const AppTagNamespaceParentQueue = "namespace.parentqueue"
+const AppTagNamespaceAllowPreemption = "namespace.allowpreemption"
 const AppTagImagePullSecrets = "imagePullSecrets"
+const AppTagTeam = "team"
+const AppTagWorkloadKind = "workloadKind"
+
+// TagContainerImages allocation tag listing the container images of the pod, comma separated
+const TagContainerImages = DomainYuniKorn + "container-images"
+
+// TagGenerateName allocation tag with the generateName prefix of the pod
+const TagGenerateName = DomainYuniKorn + "generate-name"
+
+// TagTaskGroupMinMember allocation tag with the minimum gang size set on the pod
+const TagTaskGroupMinMember = DomainYuniKorn + "task-group-min-member"
 const DefaultAppNamespace = "default"
 const DefaultUserLabel = DomainYuniKorn + "username"
 const DefaultUser = "nobody"
@@ -69,6 +88,7 @@ const LabelPlaceholderFlag = "placeholder"
 const AnnotationPlaceholderFlag = DomainYuniKorn + "placeholder"
 const AnnotationTaskGroupName = DomainYuniKorn + "task-group-name"
 const AnnotationTaskGroups = DomainYuniKorn + "task-groups"
+const AnnotationTaskGroupMinMember = DomainYuniKorn + "task-group-min-member"
 const AnnotationSchedulingPolicyParam = DomainYuniKorn + "schedulingPolicyParameters"
 const SchedulingPolicyTimeoutParam = "placeholderTimeoutInSeconds"
 const SchedulingPolicyParamDelimiter = " "
@@ -90,7 +110,8 @@ const NamespaceQuota = DomainYuniKorn + "namespace.quota"
 // NamespaceGuaranteed Namespace Guaranteed
 const NamespaceGuaranteed = DomainYuniKorn + "namespace.guaranteed"
 
-// AnnotationAllowPreemption set on PriorityClass, opt out of preemption for pods with this priority class
+// AnnotationAllowPreemption set on PriorityClass, opt out of preemption for pods with this priority class.
+// When set on a Namespace it sets the default for all pods in the namespace, set on a Pod it overrides both.
 const AnnotationAllowPreemption = DomainYuniKorn + "allow-preemption"
 
 // AnnotationIgnoreApplication set on Pod prevents by admission controller, prevents YuniKorn from honoring application ID
@@ -19,10 +19,13 @@
 package common
 
 import (
+	"sort"
+
 	"go.uber.org/zap"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
 	"github.com/apache/yunikorn-k8shim/pkg/log"
 	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
@@ -175,7 +178,32 @@ func GetNodeResource(nodeStatus *v1.NodeStatus) *si.Resource {
 	// Each kubelet can reserve some resources from the scheduler.
 	// We can rely on Allocatable resource here, because if it is not specified,
 	// the default value is same as Capacity. (same behavior as the default-scheduler)
-	return getResource(nodeStatus.Allocatable)
+	nodeResource := getResource(nodeStatus.Allocatable)
+	// the kubelet pod cap is only advertised to the core if configured
+	if !conf.GetSchedulerConf().RespectNodeMaxPods {
+		delete(nodeResource.Resources, string(v1.ResourcePods))
+	}
+	return nodeResource
+}
+
+// GetUnparseableNodeResources returns the names of the allocatable resources of the node that cannot be forwarded as
+// exact integer values: CPU values that are not a whole number of milli cores and other resources that are not a whole
+// number or do not fit an int64. The returned names are sorted.
+func GetUnparseableNodeResources(nodeStatus *v1.NodeStatus) []string {
+	var names []string
+	for name, value := range nodeStatus.Allocatable {
+		if name == v1.ResourceCPU {
+			if resource.NewMilliQuantity(value.MilliValue(), value.Format).Cmp(value) != 0 {
+				names = append(names, string(name))
+			}
+			continue
+		}
+		if _, ok := value.AsInt64(); !ok {
+			names = append(names, string(name))
+		}
+	}
+	sort.Strings(names)
+	return names
 }
 
 // parse cpu and memory from string to si.Resource, both of them are optional
@@ -347,6 +375,39 @@ func Sub(left *si.Resource, right *si.Resource) *si.Resource {
 	return result
 }
 
+// ExceedsLimit returns the sorted names of the resources in res that are larger than the limit set for the
+// same resource. Resources without a limit, or with a zero limit, are not checked.
+func ExceedsLimit(res *si.Resource, limit *si.Resource) []string {
+	var exceeded []string
+	if res == nil || limit == nil {
+		return exceeded
+	}
+	for name, limitValue := range limit.Resources {
+		if limitValue.GetValue() == 0 {
+			continue
+		}
+		if value, ok := res.Resources[name]; ok && value.GetValue() > limitValue.GetValue() {
+			exceeded = append(exceeded, name)
+		}
+	}
+	sort.Strings(exceeded)
+	return exceeded
+}
+
+// DropZeroResources returns a copy of the resource without the entries that have a zero value.
+func DropZeroResources(r *si.Resource) *si.Resource {
+	if r == nil {
+		return nil
+	}
+	result := &si.Resource{Resources: make(map[string]*si.Quantity)}
+	for name, quantity := range r.Resources {
+		if quantity.GetValue() != 0 {
+			result.Resources[name] = quantity
+		}
+	}
+	return result
+}
+
 func IsZero(r *si.Resource) bool {
 	if r == nil {
 		return true
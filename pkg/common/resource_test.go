@@ -609,6 +609,44 @@ func TestNodeResource(t *testing.T) {
 	assert.Equal(t, result.Resources[siCommon.CPU].GetValue(), int64(14500))
 }
 
+func TestGetUnparseableNodeResources(t *testing.T) {
+	allocatable := v1.ResourceList{
+		v1.ResourceCPU:         resource.MustParse("14500m"),
+		v1.ResourceMemory:      resource.MustParse("10Gi"),
+		"example.com/fraction": resource.MustParse("1.5"),
+	}
+	assert.DeepEqual(t, GetUnparseableNodeResources(&v1.NodeStatus{Allocatable: allocatable}), []string{"example.com/fraction"})
+
+	allocatable[v1.ResourceCPU] = resource.MustParse("0.0005")
+	assert.DeepEqual(t, GetUnparseableNodeResources(&v1.NodeStatus{Allocatable: allocatable}), []string{"cpu", "example.com/fraction"})
+	assert.Equal(t, len(GetUnparseableNodeResources(&v1.NodeStatus{})), 0)
+}
+
+func TestExceedsLimit(t *testing.T) {
+	res := NewResourceBuilder().
+		AddResource(siCommon.Memory, 2000).
+		AddResource(siCommon.CPU, 500).
+		AddResource("pods", 1).
+		Build()
+
+	assert.Equal(t, len(ExceedsLimit(res, nil)), 0)
+	assert.Equal(t, len(ExceedsLimit(nil, res)), 0)
+	assert.Equal(t, len(ExceedsLimit(res, res)), 0)
+
+	// zero limits and resources without a limit are not checked
+	limit := NewResourceBuilder().
+		AddResource(siCommon.Memory, 0).
+		AddResource("nvidia.com/gpu", 1).
+		Build()
+	assert.Equal(t, len(ExceedsLimit(res, limit)), 0)
+
+	limit = NewResourceBuilder().
+		AddResource(siCommon.Memory, 1000).
+		AddResource(siCommon.CPU, 100).
+		Build()
+	assert.DeepEqual(t, ExceedsLimit(res, limit), []string{siCommon.Memory, siCommon.CPU})
+}
+
 func TestIsZero(t *testing.T) {
 	r := NewResourceBuilder().
 		AddResource(siCommon.Memory, 1).
@@ -639,6 +677,21 @@ func TestIsZero(t *testing.T) {
 	assert.Equal(t, IsZero(r), true)
 }
 
+func TestDropZeroResources(t *testing.T) {
+	assert.Assert(t, DropZeroResources(nil) == nil)
+
+	r := NewResourceBuilder().
+		AddResource(siCommon.Memory, 1).
+		AddResource(siCommon.CPU, 0).
+		AddResource("nvidia.com/gpu", 0).
+		Build()
+	dropped := DropZeroResources(r)
+	assert.Equal(t, len(dropped.Resources), 1)
+	assert.Equal(t, dropped.Resources[siCommon.Memory].GetValue(), int64(1))
+	// the original resource is not changed
+	assert.Equal(t, len(r.Resources), 3)
+}
+
 func TestSub(t *testing.T) {
 	// simple case (nil checks)
 	result := Sub(nil, nil)
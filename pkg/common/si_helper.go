@@ -19,7 +19,9 @@
 package common
 
 import (
+	"fmt"
 	"strconv"
+	"strings"
 
 	v1 "k8s.io/api/core/v1"
 
@@ -61,10 +63,44 @@ func CreateTagsForTask(pod *v1.Pod) map[string]string {
 	for k, v := range pod.Labels {
 		tags[labelPrefix+k] = v
 	}
+	// add the container images if configured, init containers first
+	if conf.GetSchedulerConf().ForwardContainerImages {
+		images := make([]string, 0, len(pod.Spec.InitContainers)+len(pod.Spec.Containers))
+		for _, container := range pod.Spec.InitContainers {
+			images = append(images, container.Image)
+		}
+		for _, container := range pod.Spec.Containers {
+			images = append(images, container.Image)
+		}
+		tags[constants.TagContainerImages] = strings.Join(images, ",")
+	}
+	// add the generateName prefix if configured, this groups the pods created by the same controller
+	if conf.GetSchedulerConf().ForwardGenerateName && pod.GenerateName != "" {
+		tags[constants.TagGenerateName] = pod.GenerateName
+	}
+	// add the minimum gang size, malformed values are rejected before the task is scheduled
+	if minMember, err := GetTaskGroupMinMember(pod); err == nil && minMember > 0 {
+		tags[constants.TagTaskGroupMinMember] = strconv.FormatInt(int64(minMember), 10)
+	}
 
 	return tags
 }
 
+// GetTaskGroupMinMember returns the minimum gang size from the task group min member annotation of the pod.
+// Returns 0 if the annotation is not set and an error if the value is not a positive integer.
+func GetTaskGroupMinMember(pod *v1.Pod) (int32, error) {
+	value, ok := pod.Annotations[constants.AnnotationTaskGroupMinMember]
+	if !ok {
+		return 0, nil
+	}
+	minMember, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || minMember < 1 {
+		return 0, fmt.Errorf("invalid %s annotation value %q: must be a positive integer",
+			constants.AnnotationTaskGroupMinMember, value)
+	}
+	return int32(minMember), nil
+}
+
 func CreatePriorityForTask(pod *v1.Pod) int32 {
 	if pod.Spec.Priority != nil {
 		return *pod.Spec.Priority
@@ -178,6 +214,13 @@ func CreateUpdateRequestForNewNode(nodeID string, nodeLabels map[string]string,
 	// Add instanceType to Attributes map
 	nodeInfo.Attributes[common.InstanceType] = nodeLabels[conf.GetSchedulerConf().InstanceTypeNodeLabelKey]
 
+	// Add the scheduling domain to Attributes map if the node has the configured label
+	if domainLabel := conf.GetSchedulerConf().NodeSchedulingDomainLabel; domainLabel != "" {
+		if domain, ok := nodeLabels[domainLabel]; ok {
+			nodeInfo.Attributes[constants.DefaultNodeAttributeDomainKey] = domain
+		}
+	}
+
 	nodes := make([]*si.NodeInfo, 1)
 	nodes[0] = nodeInfo
 	return &si.NodeRequest{
@@ -25,12 +25,25 @@ import (
 	apis "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
+	"github.com/apache/yunikorn-k8shim/pkg/conf"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/common"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
 )
 
 const nodeID = "node-01"
 
+// setTestConf applies the update to a copy of the scheduler configuration and restores the previous
+// configuration when the test ends
+func setTestConf(t *testing.T, update func(c *conf.SchedulerConf)) {
+	prev := conf.GetSchedulerConf()
+	updated := prev.Clone()
+	update(updated)
+	conf.SetSchedulerConf(updated)
+	t.Cleanup(func() {
+		conf.SetSchedulerConf(prev)
+	})
+}
+
 func TestCreateReleaseRequestForTask(t *testing.T) {
 	// with allocationKey
 	request := CreateReleaseRequestForTask("app01", "task01", "task01", "default", "STOPPED_BY_RM")
@@ -214,6 +227,89 @@ func TestCreateTagsForTask(t *testing.T) {
 	assert.Equal(t, len(result4), 4)
 }
 
+func TestCreateTagsForTaskContainerImages(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+		},
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{
+				{Name: "init", Image: "busybox:1.36"},
+			},
+			Containers: []v1.Container{
+				{Name: "app", Image: "nginx:1.25"},
+				{Name: "sidecar", Image: "registry.example.com/proxy@sha256:abcd"},
+			},
+		},
+	}
+	// not forwarded by default
+	tags := CreateTagsForTask(pod)
+	_, ok := tags[constants.TagContainerImages]
+	assert.Assert(t, !ok, "container images should not be forwarded by default")
+
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.ForwardContainerImages = true
+	})
+
+	tags = CreateTagsForTask(pod)
+	assert.Equal(t, tags[constants.TagContainerImages], "busybox:1.36,nginx:1.25,registry.example.com/proxy@sha256:abcd")
+	request := CreateAllocationForTask("app01", "task01", nodeID, nil, false, "", pod, false, nil)
+	assert.Equal(t, request.Allocations[0].AllocationTags[constants.TagContainerImages], "busybox:1.36,nginx:1.25,registry.example.com/proxy@sha256:abcd")
+}
+
+func TestCreateTagsForTaskGenerateName(t *testing.T) {
+	pods := []*v1.Pod{
+		{ObjectMeta: apis.ObjectMeta{Name: "batch-job-x7k2p", GenerateName: "batch-job-", Namespace: "default"}},
+		{ObjectMeta: apis.ObjectMeta{Name: "batch-job-q9m4z", GenerateName: "batch-job-", Namespace: "default"}},
+	}
+	// not forwarded by default
+	tags := CreateTagsForTask(pods[0])
+	_, ok := tags[constants.TagGenerateName]
+	assert.Assert(t, !ok, "generateName should not be forwarded by default")
+
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.ForwardGenerateName = true
+	})
+
+	for _, pod := range pods {
+		tags = CreateTagsForTask(pod)
+		assert.Equal(t, tags[constants.TagGenerateName], "batch-job-")
+	}
+	// pods without generateName have no tag
+	tags = CreateTagsForTask(&v1.Pod{ObjectMeta: apis.ObjectMeta{Name: "test", Namespace: "default"}})
+	_, ok = tags[constants.TagGenerateName]
+	assert.Assert(t, !ok, "tag set for pod without generateName")
+}
+
+func TestCreateTagsForTaskMinMember(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: apis.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+		},
+	}
+	tags := CreateTagsForTask(pod)
+	_, ok := tags[constants.TagTaskGroupMinMember]
+	assert.Assert(t, !ok, "min member tag set without annotation")
+
+	pod.Annotations = map[string]string{constants.AnnotationTaskGroupMinMember: "3"}
+	minMember, err := GetTaskGroupMinMember(pod)
+	assert.NilError(t, err)
+	assert.Equal(t, minMember, int32(3))
+	request := CreateAllocationRequestForTask("app01", "task01", nil, false, "", pod, false, nil)
+	assert.Equal(t, request.Asks[0].Tags[constants.TagTaskGroupMinMember], "3")
+
+	for _, value := range []string{"0", "-1", "two", "1.5", ""} {
+		pod.Annotations[constants.AnnotationTaskGroupMinMember] = value
+		_, err = GetTaskGroupMinMember(pod)
+		assert.ErrorContains(t, err, "must be a positive integer", "value %q", value)
+		tags = CreateTagsForTask(pod)
+		_, ok = tags[constants.TagTaskGroupMinMember]
+		assert.Assert(t, !ok, "min member tag set for invalid value %q", value)
+	}
+}
+
 func TestCreateUpdateRequestForNewNode(t *testing.T) {
 	capacity := NewResourceBuilder().AddResource(common.Memory, 200).AddResource(common.CPU, 2).Build()
 	occupied := NewResourceBuilder().AddResource(common.Memory, 50).AddResource(common.CPU, 1).Build()
@@ -424,3 +520,30 @@ func TestCreateAllocationForTask(t *testing.T) {
 	assert.Equal(t, tags[common.DomainK8s+common.GroupMeta+"podName"], podName1)
 	assert.Equal(t, alloc1.Priority, int32(100))
 }
+
+func TestCreateUpdateRequestForNewNodeSchedulingDomain(t *testing.T) {
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.NodeSchedulingDomainLabel = "topology.kubernetes.io/zone"
+	})
+
+	capacity := NewResourceBuilder().AddResource(common.Memory, 200).AddResource(common.CPU, 2).Build()
+	occupied := NewResourceBuilder().AddResource(common.Memory, 50).AddResource(common.CPU, 1).Build()
+	domains := map[string]string{"node-a": "zone-1", "node-b": "zone-2"}
+	for node, domain := range domains {
+		request := CreateUpdateRequestForNewNode(node, map[string]string{"topology.kubernetes.io/zone": domain}, capacity, occupied, nil)
+		assert.Equal(t, request.Nodes[0].Attributes[constants.DefaultNodeAttributeDomainKey], domain)
+	}
+
+	// node without the label has no domain
+	request := CreateUpdateRequestForNewNode("node-c", map[string]string{}, capacity, occupied, nil)
+	_, ok := request.Nodes[0].Attributes[constants.DefaultNodeAttributeDomainKey]
+	assert.Assert(t, !ok, "domain set for node without domain label")
+
+	// not configured
+	setTestConf(t, func(c *conf.SchedulerConf) {
+		c.NodeSchedulingDomainLabel = ""
+	})
+	request = CreateUpdateRequestForNewNode("node-a", map[string]string{"topology.kubernetes.io/zone": "zone-1"}, capacity, occupied, nil)
+	_, ok = request.Nodes[0].Attributes[constants.DefaultNodeAttributeDomainKey]
+	assert.Assert(t, !ok, "domain set while not configured")
+}
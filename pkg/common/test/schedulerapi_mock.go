@@ -36,6 +36,7 @@ type SchedulerAPIMock struct {
 	UpdateAllocationFn  func(request *si.AllocationRequest) error
 	UpdateApplicationFn func(request *si.ApplicationRequest) error
 	UpdateNodeFn        func(request *si.NodeRequest) error
+	UpdateConfigFn      func(request *si.UpdateConfigurationRequest) error
 	lock                locking.Mutex
 }
 
@@ -58,6 +59,9 @@ func NewSchedulerAPIMock() *SchedulerAPIMock {
 		UpdateNodeFn: func(request *si.NodeRequest) error {
 			return nil
 		},
+		UpdateConfigFn: func(request *si.UpdateConfigurationRequest) error {
+			return nil
+		},
 		lock: locking.Mutex{},
 	}
 }
@@ -89,6 +93,13 @@ func (api *SchedulerAPIMock) UpdateNodeFunction(ufn func(request *si.NodeRequest
 	return api
 }
 
+func (api *SchedulerAPIMock) UpdateConfigurationFunction(ufn func(request *si.UpdateConfigurationRequest) error) *SchedulerAPIMock {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+	api.UpdateConfigFn = ufn
+	return api
+}
+
 func (api *SchedulerAPIMock) RegisterResourceManager(request *si.RegisterResourceManagerRequest,
 	callback api.ResourceManagerCallback) (*si.RegisterResourceManagerResponse, error) {
 	api.lock.Lock()
@@ -121,7 +132,7 @@ func (api *SchedulerAPIMock) UpdateNode(request *si.NodeRequest) error {
 func (api *SchedulerAPIMock) UpdateConfiguration(request *si.UpdateConfigurationRequest) error {
 	api.lock.Lock()
 	defer api.lock.Unlock()
-	return nil
+	return api.UpdateConfigFn(request)
 }
 
 func (api *SchedulerAPIMock) GetRegisterCount() int32 {
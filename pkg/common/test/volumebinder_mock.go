@@ -33,6 +33,9 @@ var _ volumebinding.SchedulerVolumeBinder = &VolumeBinderMock{}
 type VolumeBinderMock struct {
 	volumeClaimError    error
 	findPodVolumesError error
+	findPodVolumesFails int // number of calls that fail with findPodVolumesError, 0 means all calls fail
+	findPodVolumesCalls int
+	findPodVolumesHook  func() // called on every FindPodVolumes call, if set
 	assumeVolumeError   error
 	bindError           error
 	conflictReasons     volumebinding.ConflictReasons
@@ -61,8 +64,19 @@ func (v *VolumeBinderMock) GetEligibleNodes(_ klog.Logger, _ []*v1.PersistentVol
 }
 
 func (v *VolumeBinderMock) FindPodVolumes(_ klog.Logger, _ *v1.Pod, _ *volumebinding.PodVolumeClaims, _ *v1.Node) (podVolumes *volumebinding.PodVolumes, reasons volumebinding.ConflictReasons, err error) {
+	v.findPodVolumesCalls++
+	if v.findPodVolumesHook != nil {
+		v.findPodVolumesHook()
+	}
 	if v.findPodVolumesError != nil {
-		return nil, nil, v.findPodVolumesError
+		err := v.findPodVolumesError
+		if v.findPodVolumesFails > 0 {
+			v.findPodVolumesFails--
+			if v.findPodVolumesFails == 0 {
+				v.findPodVolumesError = nil
+			}
+		}
+		return nil, nil, err
 	}
 
 	if len(v.conflictReasons) > 0 {
@@ -95,6 +109,22 @@ func (v *VolumeBinderMock) EnableFindPodVolumesError(message string) {
 	v.findPodVolumesError = errors.New(message)
 }
 
+// SetFindPodVolumesError makes the next calls to FindPodVolumes fail with the given error, calls succeed again
+// after the error has been returned the given number of times.
+func (v *VolumeBinderMock) SetFindPodVolumesError(err error, times int) {
+	v.findPodVolumesError = err
+	v.findPodVolumesFails = times
+}
+
+// SetFindPodVolumesHook sets a function that is called on every call to FindPodVolumes
+func (v *VolumeBinderMock) SetFindPodVolumesHook(hook func()) {
+	v.findPodVolumesHook = hook
+}
+
+func (v *VolumeBinderMock) GetFindPodVolumesCalls() int {
+	return v.findPodVolumesCalls
+}
+
 func (v *VolumeBinderMock) SetConflictReasons(reasons ...string) {
 	var conflicts []volumebinding.ConflictReason
 	for _, r := range reasons {
@@ -77,6 +77,14 @@ func Convert2PriorityClass(obj interface{}) *schedulingv1.PriorityClass {
 	return nil
 }
 
+func Convert2Namespace(obj interface{}) *v1.Namespace {
+	if namespace, ok := obj.(*v1.Namespace); ok {
+		return namespace
+	}
+	log.Log(log.ShimUtils).Warn("cannot convert to *v1.Namespace", zap.Stringer("type", reflect.TypeOf(obj)))
+	return nil
+}
+
 // PodAlreadyBound returns true if a newly initializing Pod is already assigned to a Node
 func PodAlreadyBound(pod *v1.Pod) bool {
 	// pod already bound needs to satisfy conditions:
@@ -98,17 +106,54 @@ func IsPodTerminated(pod *v1.Pod) bool {
 	return pod.Status.Phase == v1.PodFailed || pod.Status.Phase == v1.PodSucceeded
 }
 
+// IsPodImagePullBackOff returns true if any container of the pod is waiting in an image pull back-off.
+func IsPodImagePullBackOff(pod *v1.Pod) bool {
+	for _, statuses := range [][]v1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+		for _, status := range statuses {
+			if status.State.Waiting != nil && status.State.Waiting.Reason == "ImagePullBackOff" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetPodTerminationReason returns the reason a failed pod terminated. The first container which terminated with a
+// non-zero exit code is reported, if no container status explains the failure the reason of the pod is used.
+func GetPodTerminationReason(pod *v1.Pod) string {
+	for _, statuses := range [][]v1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+		for _, status := range statuses {
+			if terminated := status.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+				return fmt.Sprintf("container %s terminated with exit code %d: %s",
+					status.Name, terminated.ExitCode, terminated.Reason)
+			}
+		}
+	}
+	if pod.Status.Reason != "" {
+		return pod.Status.Reason
+	}
+	return "unknown"
+}
+
 // assignedPod selects pods that are assigned (scheduled and running).
 func IsAssignedPod(pod *v1.Pod) bool {
 	return len(pod.Spec.NodeName) != 0
 }
 
+// IsMirrorPod returns true if the pod is a static (mirror) pod created and managed by the kubelet
+func IsMirrorPod(pod *v1.Pod) bool {
+	_, ok := pod.Annotations[v1.MirrorPodAnnotationKey]
+	return ok
+}
+
 func GetQueueNameFromPod(pod *v1.Pod) string {
 	queueName := constants.ApplicationDefaultQueue
 	if an := GetPodLabelValue(pod, constants.LabelQueueName); an != "" {
 		queueName = an
 	} else if qu := GetPodAnnotationValue(pod, constants.AnnotationQueueName); qu != "" {
 		queueName = qu
+	} else if mapped := conf.GetSchedulerConf().SchedulerNameQueueMap[pod.Spec.SchedulerName]; mapped != "" {
+		queueName = mapped
 	}
 	return queueName
 }
@@ -132,13 +177,29 @@ func GenerateApplicationID(namespace string, generateUniqueAppIds bool, podUID s
 	return fmt.Sprintf("%.63s", generatedID)
 }
 
+// isYuniKornSchedulerName returns true if the scheduler name is the YuniKorn scheduler name or one of the secondary
+// names configured in the scheduler name queue map.
+func isYuniKornSchedulerName(name string) bool {
+	if name == constants.SchedulerName {
+		return true
+	}
+	_, ok := conf.GetSchedulerConf().SchedulerNameQueueMap[name]
+	return ok
+}
+
 // GetApplicationIDFromPod returns the Application for a Pod. If a Pod is marked as schedulable by YuniKorn but is
 // missing an ApplicationID, one will be generated here (if YuniKorn is running in standard mode) or an empty string
 // will be returned (if YuniKorn is running in plugin mode).
 // If an Application ID is returned, the Pod is managed by YuniKorn. Otherwise, it is managed by an external scheduler.
+// Static (mirror) Pods are managed by the kubelet and never get an Application ID, even if they target YuniKorn.
 func GetApplicationIDFromPod(pod *v1.Pod) string {
 	// SchedulerName needs to match
-	if strings.Compare(pod.Spec.SchedulerName, constants.SchedulerName) != 0 {
+	if !isYuniKornSchedulerName(pod.Spec.SchedulerName) {
+		return ""
+	}
+
+	// mirror pods are never scheduled, only track them as occupying resources
+	if IsMirrorPod(pod) {
 		return ""
 	}
 
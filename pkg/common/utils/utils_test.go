@@ -612,6 +612,13 @@ func TestGetApplicationIDFromPod(t *testing.T) {
 			Spec: v1.PodSpec{SchedulerName: constants.SchedulerName},
 		}, sparkIDInAnnotation, sparkIDInAnnotation, false},
 		{"No AppID defined", &v1.Pod{}, "", "", false},
+		{"AppID defined on mirror pod", &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels:      map[string]string{constants.LabelApplicationID: appIDInLabel},
+				Annotations: map[string]string{v1.MirrorPodAnnotationKey: "mirror"},
+			},
+			Spec: v1.PodSpec{SchedulerName: constants.SchedulerName},
+		}, "", "", false},
 		{"Spark AppID defined in spark app selector and label", &v1.Pod{
 			ObjectMeta: metav1.ObjectMeta{
 				Labels: map[string]string{constants.SparkLabelAppID: appIDInSelector, constants.LabelApplicationID: appIDInLabel},
@@ -1125,3 +1132,14 @@ func TestConvert2PriorityClass(t *testing.T) {
 	assert.Assert(t, result != nil)
 	assert.Equal(t, result.PreemptionPolicy, &preemptLower)
 }
+
+func TestConvert2Namespace(t *testing.T) {
+	assert.Assert(t, Convert2Namespace(nil) == nil)
+	assert.Assert(t, Convert2Namespace("foo") == nil)
+
+	namespace := v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test"}}
+	assert.Assert(t, Convert2Namespace(namespace) == nil)
+	result := Convert2Namespace(&namespace)
+	assert.Assert(t, result != nil)
+	assert.Equal(t, result.Name, "test")
+}
@@ -36,6 +36,7 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/klog/v2"
 
 	"github.com/apache/yunikorn-k8shim/pkg/common/constants"
@@ -58,16 +59,58 @@ const (
 	PrefixAdmissionController = "admissionController."
 
 	// service
-	CMSvcClusterID                    = PrefixService + "clusterId"
-	CMSvcPolicyGroup                  = PrefixService + "policyGroup"
-	CMSvcSchedulingInterval           = PrefixService + "schedulingInterval"
-	CMSvcVolumeBindTimeout            = PrefixService + "volumeBindTimeout"
-	CMSvcEventChannelCapacity         = PrefixService + "eventChannelCapacity"
-	CMSvcDispatchTimeout              = PrefixService + "dispatchTimeout"
-	CMSvcDisableGangScheduling        = PrefixService + "disableGangScheduling"
-	CMSvcEnableConfigHotRefresh       = PrefixService + "enableConfigHotRefresh"
-	CMSvcPlaceholderImage             = PrefixService + "placeholderImage"
-	CMSvcNodeInstanceTypeNodeLabelKey = PrefixService + "nodeInstanceTypeNodeLabelKey"
+	CMSvcClusterID                         = PrefixService + "clusterId"
+	CMSvcPolicyGroup                       = PrefixService + "policyGroup"
+	CMSvcSchedulingInterval                = PrefixService + "schedulingInterval"
+	CMSvcVolumeBindTimeout                 = PrefixService + "volumeBindTimeout"
+	CMSvcEventChannelCapacity              = PrefixService + "eventChannelCapacity"
+	CMSvcDispatchTimeout                   = PrefixService + "dispatchTimeout"
+	CMSvcDisableGangScheduling             = PrefixService + "disableGangScheduling"
+	CMSvcEnableConfigHotRefresh            = PrefixService + "enableConfigHotRefresh"
+	CMSvcPlaceholderImage                  = PrefixService + "placeholderImage"
+	CMSvcNodeInstanceTypeNodeLabelKey      = PrefixService + "nodeInstanceTypeNodeLabelKey"
+	CMSvcRespectNodeMaxPods                = PrefixService + "respectNodeMaxPods"
+	CMSvcAllocationRequestQPS              = PrefixService + "allocationRequestQPS"
+	CMSvcAllocationRequestBurst            = PrefixService + "allocationRequestBurst"
+	CMSvcMaxPodResource                    = PrefixService + "maxPodResource"
+	CMSvcAnnotateBoundPodQueue             = PrefixService + "annotateBoundPodQueue"
+	CMSvcForwardContainerImages            = PrefixService + "forwardContainerImages"
+	CMSvcCleanupOnNamespaceDelete          = PrefixService + "cleanupOnNamespaceDelete"
+	CMSvcTeamLabelKey                      = PrefixService + "teamLabelKey"
+	CMSvcEmitNodeSchedulableEvents         = PrefixService + "emitNodeSchedulableEvents"
+	CMSvcFailTasksOnAppReject              = PrefixService + "failTasksOnAppReject"
+	CMSvcVerifyPodDeletes                  = PrefixService + "verifyPodDeletes"
+	CMSvcQueueEventTargetNamespace         = PrefixService + "queueEventTargetNamespace"
+	CMSvcNodeUpdateDebounce                = PrefixService + "nodeUpdateDebounce"
+	CMSvcAnnotateEstimatedWait             = PrefixService + "annotateEstimatedWait"
+	CMSvcReleaseTerminatingPods            = PrefixService + "releaseTerminatingPods"
+	CMSvcDefaultGroups                     = PrefixService + "defaultGroups"
+	CMSvcFailUnschedulableOversizedPods    = PrefixService + "failUnschedulableOversizedPods"
+	CMSvcAutoRemoveCompletedTasks          = PrefixService + "autoRemoveCompletedTasks"
+	CMSvcVolumeLookupRetries               = PrefixService + "volumeLookupRetries"
+	CMSvcAllowAppIDChange                  = PrefixService + "allowAppIDChange"
+	CMSvcNodeSchedulingDomainLabel         = PrefixService + "nodeSchedulingDomainLabel"
+	CMSvcImagePullBackoffTimeout           = PrefixService + "imagePullBackoffTimeout"
+	CMSvcEmitBindingDecisionEvents         = PrefixService + "emitBindingDecisionEvents"
+	CMSvcWarnOnUnparseableNodeResource     = PrefixService + "warnOnUnparseableNodeResource"
+	CMSvcForwardGenerateName               = PrefixService + "forwardGenerateName"
+	CMSvcTaskReleaseMaxRetries             = PrefixService + "taskReleaseMaxRetries"
+	CMSvcResourceSampleInterval            = PrefixService + "resourceSampleInterval"
+	CMSvcResourceSampleRetention           = PrefixService + "resourceSampleRetention"
+	CMSvcEnforceAntiAffinityLocally        = PrefixService + "enforceAntiAffinityLocally"
+	CMSvcAllocationRejectionEventThreshold = PrefixService + "allocationRejectionEventThreshold"
+	CMSvcAllocationRejectionEventWindow    = PrefixService + "allocationRejectionEventWindow"
+	CMSvcFailRestartNeverPods              = PrefixService + "failRestartNeverPods"
+	CMSvcPeriodicStateDumpInterval         = PrefixService + "periodicStateDumpInterval"
+	CMSvcPeriodicStateDumpPath             = PrefixService + "periodicStateDumpPath"
+	CMSvcPeriodicStateDumpFiles            = PrefixService + "periodicStateDumpFiles"
+	CMSvcResetReplacedNodes                = PrefixService + "resetReplacedNodes"
+	CMSvcSchedulerNameQueueMap             = PrefixService + "schedulerNameQueueMap"
+	CMSvcNodeFlapThreshold                 = PrefixService + "nodeFlapThreshold"
+	CMSvcNodeFlapWindow                    = PrefixService + "nodeFlapWindow"
+	CMSvcDropZeroResourceRequests          = PrefixService + "dropZeroResourceRequests"
+	CMSvcTagWorkloadKind                   = PrefixService + "tagWorkloadKind"
+	CMSvcRepropagatePCChanges              = PrefixService + "repropagatePCChanges"
 
 	// kubernetes
 	CMKubeQPS   = PrefixKubernetes + "qps"
@@ -88,6 +131,30 @@ const (
 	DefaultOperatorPlugins                 = "general"
 	DefaultDisableGangScheduling           = false
 	DefaultEnableConfigHotRefresh          = true
+	DefaultRespectNodeMaxPods              = true
+	DefaultAllocationRequestQPS            = 0 // unlimited
+	DefaultAllocationRequestBurst          = 10
+	DefaultAnnotateBoundPodQueue           = false
+	DefaultForwardContainerImages          = false
+	DefaultCleanupOnNamespaceDelete        = false
+	DefaultTeamLabelKey                    = "" // disabled
+	DefaultEmitNodeSchedulableEvents       = false
+	DefaultFailTasksOnAppReject            = true
+	DefaultVerifyPodDeletes                = false
+	DefaultQueueEventTargetNamespace       = "" // disabled
+	DefaultNodeUpdateDebounce              = 0  // disabled
+	DefaultAnnotateEstimatedWait           = false
+	DefaultReleaseTerminatingPods          = false
+	DefaultFailUnschedulableOversizedPods  = false
+	DefaultAutoRemoveCompletedTasks        = false
+	DefaultAllowAppIDChange                = false
+	DefaultEmitBindingDecisionEvents       = false
+	DefaultWarnOnUnparseableNodeResource   = false
+	DefaultForwardGenerateName             = false
+	DefaultResourceSampleRetention         = time.Hour
+	DefaultAllocationRejectionEventWindow  = 10 * time.Minute
+	DefaultPeriodicStateDumpFiles          = 5
+	DefaultNodeFlapWindow                  = 10 * time.Minute
 	DefaultKubeQPS                         = 1000
 	DefaultKubeBurst                       = 1000
 	DefaultAMFilteringGenerateUniqueAppIds = false
@@ -110,25 +177,67 @@ var confHolder atomic.Value
 var kubeLoggerOnce sync.Once
 
 type SchedulerConf struct {
-	SchedulerName            string        `json:"schedulerName"`
-	ClusterID                string        `json:"clusterId"`
-	ClusterVersion           string        `json:"clusterVersion"`
-	PolicyGroup              string        `json:"policyGroup"`
-	Interval                 time.Duration `json:"schedulingIntervalSecond"`
-	KubeConfig               string        `json:"absoluteKubeConfigFilePath"`
-	VolumeBindTimeout        time.Duration `json:"volumeBindTimeout"`
-	TestMode                 bool          `json:"testMode"`
-	EventChannelCapacity     int           `json:"eventChannelCapacity"`
-	DispatchTimeout          time.Duration `json:"dispatchTimeout"`
-	KubeQPS                  int           `json:"kubeQPS"`
-	KubeBurst                int           `json:"kubeBurst"`
-	EnableConfigHotRefresh   bool          `json:"enableConfigHotRefresh"`
-	DisableGangScheduling    bool          `json:"disableGangScheduling"`
-	UserLabelKey             string        `json:"userLabelKey"`
-	PlaceHolderImage         string        `json:"placeHolderImage"`
-	InstanceTypeNodeLabelKey string        `json:"instanceTypeNodeLabelKey"`
-	Namespace                string        `json:"namespace"`
-	GenerateUniqueAppIds     bool          `json:"generateUniqueAppIds"`
+	SchedulerName                     string            `json:"schedulerName"`
+	ClusterID                         string            `json:"clusterId"`
+	ClusterVersion                    string            `json:"clusterVersion"`
+	PolicyGroup                       string            `json:"policyGroup"`
+	Interval                          time.Duration     `json:"schedulingIntervalSecond"`
+	KubeConfig                        string            `json:"absoluteKubeConfigFilePath"`
+	VolumeBindTimeout                 time.Duration     `json:"volumeBindTimeout"`
+	TestMode                          bool              `json:"testMode"`
+	EventChannelCapacity              int               `json:"eventChannelCapacity"`
+	DispatchTimeout                   time.Duration     `json:"dispatchTimeout"`
+	KubeQPS                           int               `json:"kubeQPS"`
+	KubeBurst                         int               `json:"kubeBurst"`
+	EnableConfigHotRefresh            bool              `json:"enableConfigHotRefresh"`
+	DisableGangScheduling             bool              `json:"disableGangScheduling"`
+	UserLabelKey                      string            `json:"userLabelKey"`
+	PlaceHolderImage                  string            `json:"placeHolderImage"`
+	InstanceTypeNodeLabelKey          string            `json:"instanceTypeNodeLabelKey"`
+	Namespace                         string            `json:"namespace"`
+	GenerateUniqueAppIds              bool              `json:"generateUniqueAppIds"`
+	RespectNodeMaxPods                bool              `json:"respectNodeMaxPods"`
+	AllocationRequestQPS              int               `json:"allocationRequestQPS"`
+	AllocationRequestBurst            int               `json:"allocationRequestBurst"`
+	MaxPodResource                    map[string]string `json:"maxPodResource"`
+	AnnotateBoundPodQueue             bool              `json:"annotateBoundPodQueue"`
+	ForwardContainerImages            bool              `json:"forwardContainerImages"`
+	CleanupOnNamespaceDelete          bool              `json:"cleanupOnNamespaceDelete"`
+	TeamLabelKey                      string            `json:"teamLabelKey"`
+	EmitNodeSchedulableEvents         bool              `json:"emitNodeSchedulableEvents"`
+	FailTasksOnAppReject              bool              `json:"failTasksOnAppReject"`
+	VerifyPodDeletes                  bool              `json:"verifyPodDeletes"`
+	QueueEventTargetNamespace         string            `json:"queueEventTargetNamespace"`
+	NodeUpdateDebounce                time.Duration     `json:"nodeUpdateDebounce"`
+	AnnotateEstimatedWait             bool              `json:"annotateEstimatedWait"`
+	ReleaseTerminatingPods            bool              `json:"releaseTerminatingPods"`
+	DefaultGroups                     []string          `json:"defaultGroups"`
+	FailUnschedulableOversizedPods    bool              `json:"failUnschedulableOversizedPods"`
+	AutoRemoveCompletedTasks          bool              `json:"autoRemoveCompletedTasks"`
+	VolumeLookupRetries               int               `json:"volumeLookupRetries"`
+	AllowAppIDChange                  bool              `json:"allowAppIDChange"`
+	NodeSchedulingDomainLabel         string            `json:"nodeSchedulingDomainLabel"`
+	ImagePullBackoffTimeout           time.Duration     `json:"imagePullBackoffTimeout"`
+	EmitBindingDecisionEvents         bool              `json:"emitBindingDecisionEvents"`
+	WarnOnUnparseableNodeResource     bool              `json:"warnOnUnparseableNodeResource"`
+	ForwardGenerateName               bool              `json:"forwardGenerateName"`
+	TaskReleaseMaxRetries             int               `json:"taskReleaseMaxRetries"`
+	ResourceSampleInterval            time.Duration     `json:"resourceSampleInterval"`
+	ResourceSampleRetention           time.Duration     `json:"resourceSampleRetention"`
+	EnforceAntiAffinityLocally        bool              `json:"enforceAntiAffinityLocally"`
+	AllocationRejectionEventThreshold int               `json:"allocationRejectionEventThreshold"`
+	AllocationRejectionEventWindow    time.Duration     `json:"allocationRejectionEventWindow"`
+	FailRestartNeverPods              bool              `json:"failRestartNeverPods"`
+	PeriodicStateDumpInterval         time.Duration     `json:"periodicStateDumpInterval"`
+	PeriodicStateDumpPath             string            `json:"periodicStateDumpPath"`
+	PeriodicStateDumpFiles            int               `json:"periodicStateDumpFiles"`
+	ResetReplacedNodes                bool              `json:"resetReplacedNodes"`
+	SchedulerNameQueueMap             map[string]string `json:"schedulerNameQueueMap"`
+	NodeFlapThreshold                 int               `json:"nodeFlapThreshold"`
+	NodeFlapWindow                    time.Duration     `json:"nodeFlapWindow"`
+	DropZeroResourceRequests          bool              `json:"dropZeroResourceRequests"`
+	TagWorkloadKind                   bool              `json:"tagWorkloadKind"`
+	RepropagatePCChanges              bool              `json:"repropagatePCChanges"`
 
 	locking.RWMutex
 }
@@ -138,25 +247,67 @@ func (conf *SchedulerConf) Clone() *SchedulerConf {
 	defer conf.RUnlock()
 
 	return &SchedulerConf{
-		SchedulerName:            conf.SchedulerName,
-		ClusterID:                conf.ClusterID,
-		ClusterVersion:           conf.ClusterVersion,
-		PolicyGroup:              conf.PolicyGroup,
-		Interval:                 conf.Interval,
-		KubeConfig:               conf.KubeConfig,
-		VolumeBindTimeout:        conf.VolumeBindTimeout,
-		TestMode:                 conf.TestMode,
-		EventChannelCapacity:     conf.EventChannelCapacity,
-		DispatchTimeout:          conf.DispatchTimeout,
-		KubeQPS:                  conf.KubeQPS,
-		KubeBurst:                conf.KubeBurst,
-		EnableConfigHotRefresh:   conf.EnableConfigHotRefresh,
-		DisableGangScheduling:    conf.DisableGangScheduling,
-		UserLabelKey:             conf.UserLabelKey,
-		PlaceHolderImage:         conf.PlaceHolderImage,
-		InstanceTypeNodeLabelKey: conf.InstanceTypeNodeLabelKey,
-		Namespace:                conf.Namespace,
-		GenerateUniqueAppIds:     conf.GenerateUniqueAppIds,
+		SchedulerName:                     conf.SchedulerName,
+		ClusterID:                         conf.ClusterID,
+		ClusterVersion:                    conf.ClusterVersion,
+		PolicyGroup:                       conf.PolicyGroup,
+		Interval:                          conf.Interval,
+		KubeConfig:                        conf.KubeConfig,
+		VolumeBindTimeout:                 conf.VolumeBindTimeout,
+		TestMode:                          conf.TestMode,
+		EventChannelCapacity:              conf.EventChannelCapacity,
+		DispatchTimeout:                   conf.DispatchTimeout,
+		KubeQPS:                           conf.KubeQPS,
+		KubeBurst:                         conf.KubeBurst,
+		EnableConfigHotRefresh:            conf.EnableConfigHotRefresh,
+		DisableGangScheduling:             conf.DisableGangScheduling,
+		UserLabelKey:                      conf.UserLabelKey,
+		PlaceHolderImage:                  conf.PlaceHolderImage,
+		InstanceTypeNodeLabelKey:          conf.InstanceTypeNodeLabelKey,
+		Namespace:                         conf.Namespace,
+		GenerateUniqueAppIds:              conf.GenerateUniqueAppIds,
+		RespectNodeMaxPods:                conf.RespectNodeMaxPods,
+		AllocationRequestQPS:              conf.AllocationRequestQPS,
+		AllocationRequestBurst:            conf.AllocationRequestBurst,
+		MaxPodResource:                    cloneStringMap(conf.MaxPodResource),
+		AnnotateBoundPodQueue:             conf.AnnotateBoundPodQueue,
+		ForwardContainerImages:            conf.ForwardContainerImages,
+		CleanupOnNamespaceDelete:          conf.CleanupOnNamespaceDelete,
+		TeamLabelKey:                      conf.TeamLabelKey,
+		EmitNodeSchedulableEvents:         conf.EmitNodeSchedulableEvents,
+		FailTasksOnAppReject:              conf.FailTasksOnAppReject,
+		VerifyPodDeletes:                  conf.VerifyPodDeletes,
+		QueueEventTargetNamespace:         conf.QueueEventTargetNamespace,
+		NodeUpdateDebounce:                conf.NodeUpdateDebounce,
+		AnnotateEstimatedWait:             conf.AnnotateEstimatedWait,
+		ReleaseTerminatingPods:            conf.ReleaseTerminatingPods,
+		DefaultGroups:                     cloneStringSlice(conf.DefaultGroups),
+		FailUnschedulableOversizedPods:    conf.FailUnschedulableOversizedPods,
+		AutoRemoveCompletedTasks:          conf.AutoRemoveCompletedTasks,
+		VolumeLookupRetries:               conf.VolumeLookupRetries,
+		AllowAppIDChange:                  conf.AllowAppIDChange,
+		NodeSchedulingDomainLabel:         conf.NodeSchedulingDomainLabel,
+		ImagePullBackoffTimeout:           conf.ImagePullBackoffTimeout,
+		EmitBindingDecisionEvents:         conf.EmitBindingDecisionEvents,
+		WarnOnUnparseableNodeResource:     conf.WarnOnUnparseableNodeResource,
+		ForwardGenerateName:               conf.ForwardGenerateName,
+		TaskReleaseMaxRetries:             conf.TaskReleaseMaxRetries,
+		ResourceSampleInterval:            conf.ResourceSampleInterval,
+		ResourceSampleRetention:           conf.ResourceSampleRetention,
+		EnforceAntiAffinityLocally:        conf.EnforceAntiAffinityLocally,
+		AllocationRejectionEventThreshold: conf.AllocationRejectionEventThreshold,
+		AllocationRejectionEventWindow:    conf.AllocationRejectionEventWindow,
+		FailRestartNeverPods:              conf.FailRestartNeverPods,
+		PeriodicStateDumpInterval:         conf.PeriodicStateDumpInterval,
+		PeriodicStateDumpPath:             conf.PeriodicStateDumpPath,
+		PeriodicStateDumpFiles:            conf.PeriodicStateDumpFiles,
+		ResetReplacedNodes:                conf.ResetReplacedNodes,
+		SchedulerNameQueueMap:             cloneStringMap(conf.SchedulerNameQueueMap),
+		NodeFlapThreshold:                 conf.NodeFlapThreshold,
+		NodeFlapWindow:                    conf.NodeFlapWindow,
+		DropZeroResourceRequests:          conf.DropZeroResourceRequests,
+		TagWorkloadKind:                   conf.TagWorkloadKind,
+		RepropagatePCChanges:              conf.RepropagatePCChanges,
 	}
 }
 
@@ -213,6 +364,12 @@ func handleNonReloadableConfig(old *SchedulerConf, new *SchedulerConf) {
 	checkNonReloadableBool(CMSvcDisableGangScheduling, &old.DisableGangScheduling, &new.DisableGangScheduling)
 	checkNonReloadableString(CMSvcPlaceholderImage, &old.PlaceHolderImage, &new.PlaceHolderImage)
 	checkNonReloadableString(CMSvcNodeInstanceTypeNodeLabelKey, &old.InstanceTypeNodeLabelKey, &new.InstanceTypeNodeLabelKey)
+	checkNonReloadableBool(CMSvcRespectNodeMaxPods, &old.RespectNodeMaxPods, &new.RespectNodeMaxPods)
+	checkNonReloadableInt(CMSvcAllocationRequestQPS, &old.AllocationRequestQPS, &new.AllocationRequestQPS)
+	checkNonReloadableInt(CMSvcAllocationRequestBurst, &old.AllocationRequestBurst, &new.AllocationRequestBurst)
+	checkNonReloadableString(CMSvcNodeSchedulingDomainLabel, &old.NodeSchedulingDomainLabel, &new.NodeSchedulingDomainLabel)
+	checkNonReloadableDuration(CMSvcPeriodicStateDumpInterval, &old.PeriodicStateDumpInterval, &new.PeriodicStateDumpInterval)
+	checkNonReloadableBool(CMSvcTagWorkloadKind, &old.TagWorkloadKind, &new.TagWorkloadKind)
 	checkNonReloadableBool(AMFilteringGenerateUniqueAppIds, &old.GenerateUniqueAppIds, &new.GenerateUniqueAppIds)
 }
 
@@ -307,25 +464,49 @@ func GetDefaultKubeConfigPath() string {
 // CreateDefaultConfig creates and returns a configuration representing all default values
 func CreateDefaultConfig() *SchedulerConf {
 	return &SchedulerConf{
-		SchedulerName:            constants.SchedulerName,
-		Namespace:                GetSchedulerNamespace(),
-		ClusterID:                DefaultClusterID,
-		ClusterVersion:           buildVersion,
-		PolicyGroup:              DefaultPolicyGroup,
-		Interval:                 DefaultSchedulingInterval,
-		KubeConfig:               GetDefaultKubeConfigPath(),
-		VolumeBindTimeout:        DefaultVolumeBindTimeout,
-		TestMode:                 false,
-		EventChannelCapacity:     DefaultEventChannelCapacity,
-		DispatchTimeout:          DefaultDispatchTimeout,
-		KubeQPS:                  DefaultKubeQPS,
-		KubeBurst:                DefaultKubeBurst,
-		EnableConfigHotRefresh:   DefaultEnableConfigHotRefresh,
-		DisableGangScheduling:    DefaultDisableGangScheduling,
-		UserLabelKey:             constants.DefaultUserLabel,
-		PlaceHolderImage:         constants.PlaceholderContainerImage,
-		InstanceTypeNodeLabelKey: constants.DefaultNodeInstanceTypeNodeLabelKey,
-		GenerateUniqueAppIds:     DefaultAMFilteringGenerateUniqueAppIds,
+		SchedulerName:                  constants.SchedulerName,
+		Namespace:                      GetSchedulerNamespace(),
+		ClusterID:                      DefaultClusterID,
+		ClusterVersion:                 buildVersion,
+		PolicyGroup:                    DefaultPolicyGroup,
+		Interval:                       DefaultSchedulingInterval,
+		KubeConfig:                     GetDefaultKubeConfigPath(),
+		VolumeBindTimeout:              DefaultVolumeBindTimeout,
+		TestMode:                       false,
+		EventChannelCapacity:           DefaultEventChannelCapacity,
+		DispatchTimeout:                DefaultDispatchTimeout,
+		KubeQPS:                        DefaultKubeQPS,
+		KubeBurst:                      DefaultKubeBurst,
+		EnableConfigHotRefresh:         DefaultEnableConfigHotRefresh,
+		DisableGangScheduling:          DefaultDisableGangScheduling,
+		UserLabelKey:                   constants.DefaultUserLabel,
+		PlaceHolderImage:               constants.PlaceholderContainerImage,
+		InstanceTypeNodeLabelKey:       constants.DefaultNodeInstanceTypeNodeLabelKey,
+		GenerateUniqueAppIds:           DefaultAMFilteringGenerateUniqueAppIds,
+		RespectNodeMaxPods:             DefaultRespectNodeMaxPods,
+		AllocationRequestQPS:           DefaultAllocationRequestQPS,
+		AllocationRequestBurst:         DefaultAllocationRequestBurst,
+		AnnotateBoundPodQueue:          DefaultAnnotateBoundPodQueue,
+		ForwardContainerImages:         DefaultForwardContainerImages,
+		CleanupOnNamespaceDelete:       DefaultCleanupOnNamespaceDelete,
+		TeamLabelKey:                   DefaultTeamLabelKey,
+		EmitNodeSchedulableEvents:      DefaultEmitNodeSchedulableEvents,
+		FailTasksOnAppReject:           DefaultFailTasksOnAppReject,
+		VerifyPodDeletes:               DefaultVerifyPodDeletes,
+		QueueEventTargetNamespace:      DefaultQueueEventTargetNamespace,
+		NodeUpdateDebounce:             DefaultNodeUpdateDebounce,
+		AnnotateEstimatedWait:          DefaultAnnotateEstimatedWait,
+		ReleaseTerminatingPods:         DefaultReleaseTerminatingPods,
+		FailUnschedulableOversizedPods: DefaultFailUnschedulableOversizedPods,
+		AutoRemoveCompletedTasks:       DefaultAutoRemoveCompletedTasks,
+		AllowAppIDChange:               DefaultAllowAppIDChange,
+		EmitBindingDecisionEvents:      DefaultEmitBindingDecisionEvents,
+		WarnOnUnparseableNodeResource:  DefaultWarnOnUnparseableNodeResource,
+		ForwardGenerateName:            DefaultForwardGenerateName,
+		ResourceSampleRetention:        DefaultResourceSampleRetention,
+		AllocationRejectionEventWindow: DefaultAllocationRejectionEventWindow,
+		PeriodicStateDumpFiles:         DefaultPeriodicStateDumpFiles,
+		NodeFlapWindow:                 DefaultNodeFlapWindow,
 	}
 }
 
@@ -350,6 +531,48 @@ func parseConfig(config map[string]string, prev *SchedulerConf) (*SchedulerConf,
 	parser.boolVar(&conf.EnableConfigHotRefresh, CMSvcEnableConfigHotRefresh)
 	parser.stringVar(&conf.PlaceHolderImage, CMSvcPlaceholderImage)
 	parser.stringVar(&conf.InstanceTypeNodeLabelKey, CMSvcNodeInstanceTypeNodeLabelKey)
+	parser.boolVar(&conf.RespectNodeMaxPods, CMSvcRespectNodeMaxPods)
+	parser.intVar(&conf.AllocationRequestQPS, CMSvcAllocationRequestQPS)
+	parser.intVar(&conf.AllocationRequestBurst, CMSvcAllocationRequestBurst)
+	parser.resourceMapVar(&conf.MaxPodResource, CMSvcMaxPodResource)
+	parser.boolVar(&conf.AnnotateBoundPodQueue, CMSvcAnnotateBoundPodQueue)
+	parser.boolVar(&conf.ForwardContainerImages, CMSvcForwardContainerImages)
+	parser.boolVar(&conf.CleanupOnNamespaceDelete, CMSvcCleanupOnNamespaceDelete)
+	parser.stringVar(&conf.TeamLabelKey, CMSvcTeamLabelKey)
+	parser.boolVar(&conf.EmitNodeSchedulableEvents, CMSvcEmitNodeSchedulableEvents)
+	parser.boolVar(&conf.FailTasksOnAppReject, CMSvcFailTasksOnAppReject)
+	parser.boolVar(&conf.VerifyPodDeletes, CMSvcVerifyPodDeletes)
+	parser.stringVar(&conf.QueueEventTargetNamespace, CMSvcQueueEventTargetNamespace)
+	parser.durationVar(&conf.NodeUpdateDebounce, CMSvcNodeUpdateDebounce)
+	parser.boolVar(&conf.AnnotateEstimatedWait, CMSvcAnnotateEstimatedWait)
+	parser.boolVar(&conf.ReleaseTerminatingPods, CMSvcReleaseTerminatingPods)
+	parser.stringListVar(&conf.DefaultGroups, CMSvcDefaultGroups)
+	parser.boolVar(&conf.FailUnschedulableOversizedPods, CMSvcFailUnschedulableOversizedPods)
+	parser.boolVar(&conf.AutoRemoveCompletedTasks, CMSvcAutoRemoveCompletedTasks)
+	parser.intVar(&conf.VolumeLookupRetries, CMSvcVolumeLookupRetries)
+	parser.boolVar(&conf.AllowAppIDChange, CMSvcAllowAppIDChange)
+	parser.stringVar(&conf.NodeSchedulingDomainLabel, CMSvcNodeSchedulingDomainLabel)
+	parser.durationVar(&conf.ImagePullBackoffTimeout, CMSvcImagePullBackoffTimeout)
+	parser.boolVar(&conf.EmitBindingDecisionEvents, CMSvcEmitBindingDecisionEvents)
+	parser.boolVar(&conf.WarnOnUnparseableNodeResource, CMSvcWarnOnUnparseableNodeResource)
+	parser.boolVar(&conf.ForwardGenerateName, CMSvcForwardGenerateName)
+	parser.intVar(&conf.TaskReleaseMaxRetries, CMSvcTaskReleaseMaxRetries)
+	parser.durationVar(&conf.ResourceSampleInterval, CMSvcResourceSampleInterval)
+	parser.durationVar(&conf.ResourceSampleRetention, CMSvcResourceSampleRetention)
+	parser.boolVar(&conf.EnforceAntiAffinityLocally, CMSvcEnforceAntiAffinityLocally)
+	parser.intVar(&conf.AllocationRejectionEventThreshold, CMSvcAllocationRejectionEventThreshold)
+	parser.durationVar(&conf.AllocationRejectionEventWindow, CMSvcAllocationRejectionEventWindow)
+	parser.boolVar(&conf.FailRestartNeverPods, CMSvcFailRestartNeverPods)
+	parser.durationVar(&conf.PeriodicStateDumpInterval, CMSvcPeriodicStateDumpInterval)
+	parser.stringVar(&conf.PeriodicStateDumpPath, CMSvcPeriodicStateDumpPath)
+	parser.intVar(&conf.PeriodicStateDumpFiles, CMSvcPeriodicStateDumpFiles)
+	parser.boolVar(&conf.ResetReplacedNodes, CMSvcResetReplacedNodes)
+	parser.stringMapVar(&conf.SchedulerNameQueueMap, CMSvcSchedulerNameQueueMap)
+	parser.intVar(&conf.NodeFlapThreshold, CMSvcNodeFlapThreshold)
+	parser.durationVar(&conf.NodeFlapWindow, CMSvcNodeFlapWindow)
+	parser.boolVar(&conf.DropZeroResourceRequests, CMSvcDropZeroResourceRequests)
+	parser.boolVar(&conf.TagWorkloadKind, CMSvcTagWorkloadKind)
+	parser.boolVar(&conf.RepropagatePCChanges, CMSvcRepropagatePCChanges)
 
 	// kubernetes
 	parser.intVar(&conf.KubeQPS, CMKubeQPS)
@@ -419,6 +642,101 @@ func (cp *configParser) durationVar(p *time.Duration, name string) {
 	}
 }
 
+// resourceMapVar parses a comma separated list of resource=quantity pairs, e.g. "cpu=16,memory=64Gi".
+// An empty value clears the map.
+func (cp *configParser) resourceMapVar(p *map[string]string, name string) {
+	if newValue, ok := cp.config[name]; ok {
+		resources := make(map[string]string)
+		for _, entry := range strings.Split(newValue, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			resName, quantity, found := strings.Cut(entry, "=")
+			resName = strings.TrimSpace(resName)
+			quantity = strings.TrimSpace(quantity)
+			if !found || resName == "" {
+				err := fmt.Errorf("invalid resource entry %q, expected name=quantity", entry)
+				log.Log(log.ShimConfig).Error("Unable to parse configmap entry", zap.String("key", name), zap.String("value", newValue), zap.Error(err))
+				cp.errors = append(cp.errors, err)
+				return
+			}
+			if _, err := resource.ParseQuantity(quantity); err != nil {
+				log.Log(log.ShimConfig).Error("Unable to parse configmap entry", zap.String("key", name), zap.String("value", newValue), zap.Error(err))
+				cp.errors = append(cp.errors, err)
+				return
+			}
+			resources[resName] = quantity
+		}
+		if len(resources) == 0 {
+			resources = nil
+		}
+		*p = resources
+	}
+}
+
+// stringMapVar parses a comma separated list of key=value pairs, empty entries are ignored.
+// An empty value clears the map, an entry without a key or value is an error.
+func (cp *configParser) stringMapVar(p *map[string]string, name string) {
+	if newValue, ok := cp.config[name]; ok {
+		values := make(map[string]string)
+		for _, entry := range strings.Split(newValue, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			key, value, found := strings.Cut(entry, "=")
+			key = strings.TrimSpace(key)
+			value = strings.TrimSpace(value)
+			if !found || key == "" || value == "" {
+				err := fmt.Errorf("invalid entry %q, expected key=value", entry)
+				log.Log(log.ShimConfig).Error("Unable to parse configmap entry", zap.String("key", name), zap.String("value", newValue), zap.Error(err))
+				cp.errors = append(cp.errors, err)
+				return
+			}
+			values[key] = value
+		}
+		if len(values) == 0 {
+			values = nil
+		}
+		*p = values
+	}
+}
+
+// stringListVar parses a comma separated list of strings, empty entries are ignored.
+// An empty value clears the list.
+func (cp *configParser) stringListVar(p *[]string, name string) {
+	if newValue, ok := cp.config[name]; ok {
+		var values []string
+		for _, entry := range strings.Split(newValue, ",") {
+			if entry = strings.TrimSpace(entry); entry != "" {
+				values = append(values, entry)
+			}
+		}
+		*p = values
+	}
+}
+
+func cloneStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	clone := make(map[string]string, len(m))
+	for k, v := range m {
+		clone[k] = v
+	}
+	return clone
+}
+
+func cloneStringSlice(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	clone := make([]string, len(s))
+	copy(clone, s)
+	return clone
+}
+
 func updateKubeLogger() {
 	// if log level is debug, enable klog and set its log level verbosity to 4 (represents debug level),
 	// For details refer to the Logging Conventions of klog at
@@ -124,6 +124,45 @@ func TestParseConfigMap(t *testing.T) {
 		{CMSvcEnableConfigHotRefresh, "EnableConfigHotRefresh", false},
 		{CMSvcPlaceholderImage, "PlaceHolderImage", "test-image"},
 		{CMSvcNodeInstanceTypeNodeLabelKey, "InstanceTypeNodeLabelKey", "node.kubernetes.io/instance-type"},
+		{CMSvcRespectNodeMaxPods, "RespectNodeMaxPods", false},
+		{CMSvcAllocationRequestQPS, "AllocationRequestQPS", 50},
+		{CMSvcAllocationRequestBurst, "AllocationRequestBurst", 20},
+		{CMSvcAnnotateBoundPodQueue, "AnnotateBoundPodQueue", true},
+		{CMSvcForwardContainerImages, "ForwardContainerImages", true},
+		{CMSvcCleanupOnNamespaceDelete, "CleanupOnNamespaceDelete", true},
+		{CMSvcTeamLabelKey, "TeamLabelKey", "example.com/team"},
+		{CMSvcEmitNodeSchedulableEvents, "EmitNodeSchedulableEvents", true},
+		{CMSvcFailTasksOnAppReject, "FailTasksOnAppReject", false},
+		{CMSvcVerifyPodDeletes, "VerifyPodDeletes", true},
+		{CMSvcQueueEventTargetNamespace, "QueueEventTargetNamespace", "yunikorn"},
+		{CMSvcNodeUpdateDebounce, "NodeUpdateDebounce", 5 * time.Second},
+		{CMSvcAnnotateEstimatedWait, "AnnotateEstimatedWait", true},
+		{CMSvcReleaseTerminatingPods, "ReleaseTerminatingPods", true},
+		{CMSvcFailUnschedulableOversizedPods, "FailUnschedulableOversizedPods", true},
+		{CMSvcAutoRemoveCompletedTasks, "AutoRemoveCompletedTasks", true},
+		{CMSvcVolumeLookupRetries, "VolumeLookupRetries", 3},
+		{CMSvcAllowAppIDChange, "AllowAppIDChange", true},
+		{CMSvcNodeSchedulingDomainLabel, "NodeSchedulingDomainLabel", "topology.kubernetes.io/zone"},
+		{CMSvcImagePullBackoffTimeout, "ImagePullBackoffTimeout", 5 * time.Minute},
+		{CMSvcEmitBindingDecisionEvents, "EmitBindingDecisionEvents", true},
+		{CMSvcWarnOnUnparseableNodeResource, "WarnOnUnparseableNodeResource", true},
+		{CMSvcForwardGenerateName, "ForwardGenerateName", true},
+		{CMSvcTaskReleaseMaxRetries, "TaskReleaseMaxRetries", 3},
+		{CMSvcResourceSampleInterval, "ResourceSampleInterval", 30 * time.Second},
+		{CMSvcResourceSampleRetention, "ResourceSampleRetention", 2 * time.Hour},
+		{CMSvcEnforceAntiAffinityLocally, "EnforceAntiAffinityLocally", true},
+		{CMSvcAllocationRejectionEventThreshold, "AllocationRejectionEventThreshold", 3},
+		{CMSvcAllocationRejectionEventWindow, "AllocationRejectionEventWindow", 5 * time.Minute},
+		{CMSvcFailRestartNeverPods, "FailRestartNeverPods", true},
+		{CMSvcPeriodicStateDumpInterval, "PeriodicStateDumpInterval", time.Minute},
+		{CMSvcPeriodicStateDumpPath, "PeriodicStateDumpPath", "/tmp/yunikorn"},
+		{CMSvcPeriodicStateDumpFiles, "PeriodicStateDumpFiles", 3},
+		{CMSvcResetReplacedNodes, "ResetReplacedNodes", true},
+		{CMSvcNodeFlapThreshold, "NodeFlapThreshold", 4},
+		{CMSvcNodeFlapWindow, "NodeFlapWindow", 5 * time.Minute},
+		{CMSvcDropZeroResourceRequests, "DropZeroResourceRequests", true},
+		{CMSvcTagWorkloadKind, "TagWorkloadKind", true},
+		{CMSvcRepropagatePCChanges, "RepropagatePCChanges", true},
 		{CMKubeQPS, "KubeQPS", 2345},
 		{CMKubeBurst, "KubeBurst", 3456},
 	}
@@ -139,6 +178,74 @@ func TestParseConfigMap(t *testing.T) {
 	}
 }
 
+func TestParseMaxPodResource(t *testing.T) {
+	prev := CreateDefaultConfig()
+	assert.Assert(t, prev.MaxPodResource == nil)
+
+	conf, errs := parseConfig(map[string]string{CMSvcMaxPodResource: "cpu=16, memory=64Gi"}, prev)
+	assert.Assert(t, errs == nil, errs)
+	assert.DeepEqual(t, conf.MaxPodResource, map[string]string{"cpu": "16", "memory": "64Gi"})
+
+	// clone must not share the map
+	clone := conf.Clone()
+	clone.MaxPodResource["cpu"] = "1"
+	assert.Equal(t, conf.MaxPodResource["cpu"], "16")
+
+	// empty value disables
+	conf, errs = parseConfig(map[string]string{CMSvcMaxPodResource: ""}, conf)
+	assert.Assert(t, errs == nil, errs)
+	assert.Assert(t, conf.MaxPodResource == nil)
+
+	_, errs = parseConfig(map[string]string{CMSvcMaxPodResource: "cpu"}, prev)
+	assert.Equal(t, len(errs), 1)
+	_, errs = parseConfig(map[string]string{CMSvcMaxPodResource: "cpu=lots"}, prev)
+	assert.Equal(t, len(errs), 1)
+}
+
+func TestParseDefaultGroups(t *testing.T) {
+	prev := CreateDefaultConfig()
+	assert.Assert(t, prev.DefaultGroups == nil)
+
+	conf, errs := parseConfig(map[string]string{CMSvcDefaultGroups: "staff, ,analysts"}, prev)
+	assert.Assert(t, errs == nil, errs)
+	assert.DeepEqual(t, conf.DefaultGroups, []string{"staff", "analysts"})
+
+	// clone must not share the slice
+	clone := conf.Clone()
+	clone.DefaultGroups[0] = "other"
+	assert.Equal(t, conf.DefaultGroups[0], "staff")
+
+	// empty value disables
+	conf, errs = parseConfig(map[string]string{CMSvcDefaultGroups: ""}, conf)
+	assert.Assert(t, errs == nil, errs)
+	assert.Assert(t, conf.DefaultGroups == nil)
+}
+
+func TestParseSchedulerNameQueueMap(t *testing.T) {
+	prev := CreateDefaultConfig()
+	assert.Assert(t, prev.SchedulerNameQueueMap == nil)
+
+	conf, errs := parseConfig(map[string]string{CMSvcSchedulerNameQueueMap: "yunikorn-batch=root.batch, yunikorn-ml = root.ml"}, prev)
+	assert.Assert(t, errs == nil, errs)
+	assert.DeepEqual(t, conf.SchedulerNameQueueMap, map[string]string{"yunikorn-batch": "root.batch", "yunikorn-ml": "root.ml"})
+
+	// clone must not share the map
+	clone := conf.Clone()
+	clone.SchedulerNameQueueMap["yunikorn-batch"] = "root.other"
+	assert.Equal(t, conf.SchedulerNameQueueMap["yunikorn-batch"], "root.batch")
+
+	// empty value disables
+	conf, errs = parseConfig(map[string]string{CMSvcSchedulerNameQueueMap: ""}, conf)
+	assert.Assert(t, errs == nil, errs)
+	assert.Assert(t, conf.SchedulerNameQueueMap == nil)
+
+	// invalid entries
+	_, errs = parseConfig(map[string]string{CMSvcSchedulerNameQueueMap: "yunikorn-batch"}, prev)
+	assert.Equal(t, len(errs), 1)
+	_, errs = parseConfig(map[string]string{CMSvcSchedulerNameQueueMap: "yunikorn-batch="}, prev)
+	assert.Equal(t, len(errs), 1)
+}
+
 func TestUpdateConfigMapNonReloadable(t *testing.T) {
 	testCases := []struct {
 		name       string
@@ -155,6 +262,45 @@ func TestUpdateConfigMapNonReloadable(t *testing.T) {
 		{CMSvcDisableGangScheduling, "DisableGangScheduling", true, false},
 		{CMSvcPlaceholderImage, "PlaceHolderImage", "test-image", false},
 		{CMSvcNodeInstanceTypeNodeLabelKey, "InstanceTypeNodeLabelKey", "node.kubernetes.io/instance-type", false},
+		{CMSvcRespectNodeMaxPods, "RespectNodeMaxPods", false, false},
+		{CMSvcAllocationRequestQPS, "AllocationRequestQPS", 50, false},
+		{CMSvcAllocationRequestBurst, "AllocationRequestBurst", 20, false},
+		{CMSvcAnnotateBoundPodQueue, "AnnotateBoundPodQueue", true, true},
+		{CMSvcForwardContainerImages, "ForwardContainerImages", true, true},
+		{CMSvcCleanupOnNamespaceDelete, "CleanupOnNamespaceDelete", true, true},
+		{CMSvcTeamLabelKey, "TeamLabelKey", "example.com/team", true},
+		{CMSvcEmitNodeSchedulableEvents, "EmitNodeSchedulableEvents", true, true},
+		{CMSvcFailTasksOnAppReject, "FailTasksOnAppReject", false, true},
+		{CMSvcVerifyPodDeletes, "VerifyPodDeletes", true, true},
+		{CMSvcQueueEventTargetNamespace, "QueueEventTargetNamespace", "yunikorn", true},
+		{CMSvcNodeUpdateDebounce, "NodeUpdateDebounce", 5 * time.Second, true},
+		{CMSvcAnnotateEstimatedWait, "AnnotateEstimatedWait", true, true},
+		{CMSvcReleaseTerminatingPods, "ReleaseTerminatingPods", true, true},
+		{CMSvcFailUnschedulableOversizedPods, "FailUnschedulableOversizedPods", true, true},
+		{CMSvcAutoRemoveCompletedTasks, "AutoRemoveCompletedTasks", true, true},
+		{CMSvcVolumeLookupRetries, "VolumeLookupRetries", 3, true},
+		{CMSvcAllowAppIDChange, "AllowAppIDChange", true, true},
+		{CMSvcNodeSchedulingDomainLabel, "NodeSchedulingDomainLabel", "topology.kubernetes.io/zone", false},
+		{CMSvcImagePullBackoffTimeout, "ImagePullBackoffTimeout", 5 * time.Minute, true},
+		{CMSvcEmitBindingDecisionEvents, "EmitBindingDecisionEvents", true, true},
+		{CMSvcWarnOnUnparseableNodeResource, "WarnOnUnparseableNodeResource", true, true},
+		{CMSvcForwardGenerateName, "ForwardGenerateName", true, true},
+		{CMSvcTaskReleaseMaxRetries, "TaskReleaseMaxRetries", 3, true},
+		{CMSvcResourceSampleInterval, "ResourceSampleInterval", 30 * time.Second, true},
+		{CMSvcResourceSampleRetention, "ResourceSampleRetention", 2 * time.Hour, true},
+		{CMSvcEnforceAntiAffinityLocally, "EnforceAntiAffinityLocally", true, true},
+		{CMSvcAllocationRejectionEventThreshold, "AllocationRejectionEventThreshold", 3, true},
+		{CMSvcAllocationRejectionEventWindow, "AllocationRejectionEventWindow", 5 * time.Minute, true},
+		{CMSvcFailRestartNeverPods, "FailRestartNeverPods", true, true},
+		{CMSvcPeriodicStateDumpInterval, "PeriodicStateDumpInterval", time.Minute, false},
+		{CMSvcPeriodicStateDumpPath, "PeriodicStateDumpPath", "/tmp/yunikorn", true},
+		{CMSvcPeriodicStateDumpFiles, "PeriodicStateDumpFiles", 3, true},
+		{CMSvcResetReplacedNodes, "ResetReplacedNodes", true, true},
+		{CMSvcNodeFlapThreshold, "NodeFlapThreshold", 4, true},
+		{CMSvcNodeFlapWindow, "NodeFlapWindow", 5 * time.Minute, true},
+		{CMSvcDropZeroResourceRequests, "DropZeroResourceRequests", true, true},
+		{CMSvcTagWorkloadKind, "TagWorkloadKind", true, false},
+		{CMSvcRepropagatePCChanges, "RepropagatePCChanges", true, true},
 		{CMKubeQPS, "KubeQPS", 2345, false},
 		{CMKubeBurst, "KubeBurst", 3456, false},
 	}
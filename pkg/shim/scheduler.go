@@ -115,6 +115,8 @@ func (ss *KubernetesShim) doScheduling() {
 	go wait.Until(ss.schedule, conf.GetSchedulerConf().GetSchedulingInterval(), ss.stopChan)
 	// log a message if no outstanding requests were found for a while
 	go wait.Until(ss.checkOutstandingApps, outstandingAppLogTimeout, ss.stopChan)
+	// write the state dump periodically, if configured
+	ss.context.RunPeriodicStateDump(ss.stopChan)
 }
 
 func (ss *KubernetesShim) registerShimLayer() error {
@@ -146,12 +148,8 @@ func (ss *KubernetesShim) registerShimLayer() error {
 		zap.String("clusterVersion", configuration.ClusterVersion),
 		zap.String("policyGroup", configuration.PolicyGroup),
 		zap.Any("buildInfo", buildInfoMap))
-	if _, err := ss.apiFactory.GetAPIs().SchedulerAPI.
-		RegisterResourceManager(&registerMessage, ss.callback); err != nil {
-		return err
-	}
-
-	return nil
+	_, err = ss.apiFactory.GetAPIs().SchedulerAPI.RegisterResourceManager(&registerMessage, ss.callback)
+	return ss.context.TrackSchedulerCall(err)
 }
 
 // each schedule iteration, we scan all apps and triggers app state transition
@@ -164,6 +162,9 @@ func (ss *KubernetesShim) schedule() {
 			}
 			continue
 		}
+		if ss.context.IsQueueSuspended(app.GetQueue()) {
+			continue
+		}
 
 		if app.Schedule() {
 			ss.setOutstandingAppsFound(true)
@@ -214,6 +215,8 @@ func (ss *KubernetesShim) Stop() {
 		dispatcher.Stop()
 		// stop the placeholder manager
 		ss.phManager.Stop()
+		// drop the delayed updates to the core
+		ss.context.Stop()
 	default:
 		log.Log(log.ShimScheduler).Info("scheduler is already stopped")
 	}